@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// 权限范围
+const (
+	scopeChat        = "chat"         // /chat、/chat/stream、会话增删改
+	scopeHistoryRead = "history:read" // /history、会话列表与消息
+	scopeAdmin       = "admin"        // /admin/keys
+)
+
+var knownScopes = []string{scopeChat, scopeHistoryRead, scopeAdmin}
+
+var (
+	errUnauthenticated = status.Error(codes.Unauthenticated, "invalid api key")
+	errKeyNotFound     = status.Error(codes.NotFound, "api key not found")
+)
+
+// 缓存 key 用哈希而不是明文，Redis 里也不落明文
+func akey(hash string) string { return "apikey:" + hash }
+
+func hashKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// newSecret 生成明文 key：sk- + 48 位十六进制；prefix 用于列表展示
+func newSecret() (secret, prefix string, err error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret = "sk-" + hex.EncodeToString(b)
+	return secret, secret[:10], nil
+}
+
+// keyID 校验并解析 key ID（对外是不透明字符串，库里是自增主键）
+func keyID(id string) (int64, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "bad key id %q", id)
+	}
+	return n, nil
+}
+
+// normScopes 去重、排序并校验；不传时默认 chat + history:read
+func normScopes(in []string) (string, error) {
+	if len(in) == 0 {
+		return scopeChat + "," + scopeHistoryRead, nil
+	}
+	var out []string
+	for _, s := range in {
+		s = strings.TrimSpace(s)
+		if !slices.Contains(knownScopes, s) {
+			return "", status.Errorf(codes.InvalidArgument, "unknown scope %q", s)
+		}
+		if !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	slices.Sort(out)
+	return strings.Join(out, ","), nil
+}
+
+const keyCols = "id, prefix, user_id, tenant_id, scopes, name, UNIX_TIMESTAMP(created_at), COALESCE(UNIX_TIMESTAMP(revoked_at), 0)"
+
+type scanner interface{ Scan(dest ...any) error }
+
+func scanKey(row scanner) (*pb.ApiKey, error) {
+	var id int64
+	var scopes string
+	k := &pb.ApiKey{}
+	if err := row.Scan(&id, &k.Prefix, &k.UserId, &k.TenantId, &scopes, &k.Name, &k.CreatedAt, &k.RevokedAt); err != nil {
+		return nil, err
+	}
+	k.Id = strconv.FormatInt(id, 10)
+	k.Scopes = strings.Split(scopes, ",")
+	return k, nil
+}
+
+func (s *server) Authenticate(ctx context.Context, in *pb.AuthenticateRequest) (*pb.ApiKey, error) {
+	if !strings.HasPrefix(in.Key, "sk-") {
+		return nil, errUnauthenticated
+	}
+	hash := hashKey(in.Key)
+
+	// 1) 先查 Redis
+	if s.rdb != nil {
+		if b, err := s.rdb.Get(ctx, akey(hash)).Bytes(); err == nil {
+			k := &pb.ApiKey{}
+			if protojson.Unmarshal(b, k) == nil {
+				return k, nil
+			}
+		}
+	}
+
+	// 2) Miss：查 MySQL；不存在或已吊销一律 Unauthenticated（不区分，避免探测）
+	k, err := scanKey(s.db.QueryRowContext(ctx, "SELECT "+keyCols+" FROM api_keys WHERE key_hash=?", hash))
+	if err == sql.ErrNoRows {
+		return nil, errUnauthenticated
+	}
+	if err != nil {
+		return nil, err
+	}
+	if k.RevokedAt != 0 {
+		return nil, errUnauthenticated
+	}
+
+	// 3) 回填缓存（只缓存有效 key）
+	if s.rdb != nil {
+		if b, err := protojson.Marshal(k); err == nil {
+			_ = s.rdb.Set(ctx, akey(hash), b, s.cacheTTL).Err()
+		}
+	}
+	return k, nil
+}
+
+// 网关确定的用户身份（user、tenant/user）写入历史与会话表的 user_id 列，不能超过列宽
+const maxUserID = 64
+
+// checkOwner 校验 key 所属的用户与租户：至少给出一个；"/" 用作租户与终端用户的分隔符（tenant/user），
+// 不允许出现在 ID 里；拼出的身份不超过 maxUserID。租户 key（无 user_id）由租户后端代终端用户调用，
+// 租户 ID 至少要给 "/" 加一个字符的用户 ID 留出位置
+func checkOwner(user, tenant string) error {
+	if user == "" && tenant == "" {
+		return status.Error(codes.InvalidArgument, "user_id or tenant_id is required")
+	}
+	if strings.Contains(user, "/") || strings.Contains(tenant, "/") {
+		return status.Error(codes.InvalidArgument, "user_id and tenant_id must not contain '/'")
+	}
+	n := len(user)
+	if tenant != "" {
+		n = len(tenant) + 1 + max(len(user), 1)
+	}
+	if n > maxUserID {
+		return status.Errorf(codes.InvalidArgument, "tenant_id/user_id must fit %d bytes", maxUserID)
+	}
+	return nil
+}
+
+func (s *server) IssueKey(ctx context.Context, in *pb.IssueKeyRequest) (*pb.IssueKeyReply, error) {
+	if err := checkOwner(in.UserId, in.TenantId); err != nil {
+		return nil, err
+	}
+	scopes, err := normScopes(in.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	secret, prefix, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO api_keys(key_hash, prefix, user_id, tenant_id, scopes, name) VALUES(?,?,?,?,?,?)",
+		hashKey(secret), prefix, in.UserId, in.TenantId, scopes, in.Name)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.IssueKeyReply{
+		Key: &pb.ApiKey{
+			Id: strconv.FormatInt(id, 10), Prefix: prefix, UserId: in.UserId, TenantId: in.TenantId,
+			Scopes: strings.Split(scopes, ","), Name: in.Name, CreatedAt: time.Now().Unix(),
+		},
+		Secret: secret,
+	}, nil
+}
+
+func (s *server) ListKeys(ctx context.Context, in *pb.ListKeysRequest) (*pb.ListKeysReply, error) {
+	where := "1=1"
+	var args []any
+	if in.UserId != "" {
+		where += " AND user_id=?"
+		args = append(args, in.UserId)
+	}
+	if in.TenantId != "" {
+		where += " AND tenant_id=?"
+		args = append(args, in.TenantId)
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT "+keyCols+" FROM api_keys WHERE "+where+" ORDER BY id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*pb.ApiKey
+	for rows.Next() {
+		k, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return &pb.ListKeysReply{Keys: keys}, rows.Err()
+}
+
+// RotateKey 为同一个 key（ID、绑定、权限不变）换一个新明文，旧明文立即失效
+func (s *server) RotateKey(ctx context.Context, in *pb.RotateKeyRequest) (*pb.IssueKeyReply, error) {
+	id, err := keyID(in.Id)
+	if err != nil {
+		return nil, err
+	}
+	secret, prefix, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	oldHash, err := s.lockKey(ctx, tx, id, in.TenantId, "AND revoked_at IS NULL")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE api_keys SET key_hash=?, prefix=? WHERE id=?", hashKey(secret), prefix, id); err != nil {
+		return nil, err
+	}
+	k, err := scanKey(tx.QueryRowContext(ctx, "SELECT "+keyCols+" FROM api_keys WHERE id=?", id))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.evict(ctx, oldHash)
+	return &pb.IssueKeyReply{Key: k, Secret: secret}, nil
+}
+
+// RevokeKey 吊销 key；重复吊销视为成功
+func (s *server) RevokeKey(ctx context.Context, in *pb.RevokeKeyRequest) (*pb.RevokeKeyReply, error) {
+	id, err := keyID(in.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	hash, err := s.lockKey(ctx, tx, id, in.TenantId, "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE api_keys SET revoked_at=CURRENT_TIMESTAMP WHERE id=? AND revoked_at IS NULL", id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.evict(ctx, hash)
+	return &pb.RevokeKeyReply{Ok: true}, nil
+}
+
+// lockKey 锁定 key 行并返回当前哈希；tenant 非空时只能操作该租户的 key（否则视为不存在）
+func (s *server) lockKey(ctx context.Context, tx *sql.Tx, id int64, tenant, cond string) (string, error) {
+	q := "SELECT key_hash FROM api_keys WHERE id=? " + cond
+	args := []any{id}
+	if tenant != "" {
+		q += " AND tenant_id=?"
+		args = append(args, tenant)
+	}
+	var hash string
+	err := tx.QueryRowContext(ctx, q+" FOR UPDATE", args...).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", errKeyNotFound
+	}
+	return hash, err
+}
+
+// evict 删除校验缓存；用不受调用方取消影响的上下文，避免留下仍然有效的旧缓存
+func (s *server) evict(ctx context.Context, hash string) {
+	if s.rdb != nil {
+		_ = s.rdb.Del(context.WithoutCancel(ctx), akey(hash)).Err()
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 拼出的身份（user、tenant/user、tenant/<终端用户>）必须放得进 64 字节的 user_id 列
+func TestCheckOwner(t *testing.T) {
+	for _, tc := range []struct {
+		name, user, tenant string
+		ok                 bool
+	}{
+		{"user", "u1", "", true},
+		{"tenant key", "", "acme", true},
+		{"tenant user", "u1", "acme", true},
+		{"empty", "", "", false},
+		{"slash in user", "a/b", "", false},
+		{"slash in tenant", "u1", "a/b", false},
+		{"user at limit", strings.Repeat("u", 64), "", true},
+		{"user too long", strings.Repeat("u", 65), "", false},
+		{"tenant user at limit", strings.Repeat("u", 31), strings.Repeat("t", 32), true},
+		{"tenant user too long", strings.Repeat("u", 32), strings.Repeat("t", 32), false},
+		{"tenant key at limit", "", strings.Repeat("t", 62), true},
+		{"tenant key leaves no room", "", strings.Repeat("t", 63), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkOwner(tc.user, tc.tenant)
+			if tc.ok && err != nil {
+				t.Fatalf("checkOwner(%q, %q) = %v, want ok", tc.user, tc.tenant, err)
+			}
+			if !tc.ok && status.Code(err) != codes.InvalidArgument {
+				t.Fatalf("checkOwner(%q, %q) = %v, want InvalidArgument", tc.user, tc.tenant, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/config"
+	"chatgpt-demo/logging"
+	"chatgpt-demo/metrics"
+	"chatgpt-demo/tracing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+)
+
+type server struct {
+	pb.UnimplementedAuthServiceServer
+	db  *sql.DB
+	rdb *redis.Client
+	// 校验结果在 Redis 里的缓存时长（默认 5 分钟）；轮换/吊销时主动删除，不必等过期
+	cacheTTL time.Duration
+}
+
+func main() {
+	logging.Init("authserver")
+	// 首个管理员 key 只能从命令行签发：go run ./authserver -issue-admin ops
+	issueAdmin := flag.String("issue-admin", "", "issue an admin API key for this user id, print it and exit")
+	cfg := config.MustLoad("authserver")
+
+	shutdown, err := tracing.Init(context.Background(), "authserver")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
+	db, err := tracing.OpenMySQL(cfg.MySQL.DataSource())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Redis：校验结果缓存
+	rdb := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr})
+	tracing.InstrumentRedis(rdb)
+
+	srv := &server{db: db, rdb: rdb, cacheTTL: cfg.Auth.CacheTTL.Duration}
+
+	if *issueAdmin != "" {
+		r, err := srv.IssueKey(context.Background(), &pb.IssueKeyRequest{
+			UserId: *issueAdmin, Scopes: []string{scopeChat, scopeHistoryRead, scopeAdmin}, Name: "bootstrap",
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("id=%s user=%s scopes=%s\n%s\n", r.Key.Id, r.Key.UserId, strings.Join(r.Key.Scopes, ","), r.Secret)
+		return
+	}
+
+	metrics.DBPool(db, "chatdb")
+	metrics.RedisPool(rdb)
+	metrics.Serve(cfg.Auth.MetricsAddr)
+
+	lis, err := net.Listen("tcp", cfg.Auth.Addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(logging.UnaryServerInterceptor, metrics.UnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(logging.StreamServerInterceptor, metrics.StreamServerInterceptor),
+	)
+	pb.RegisterAuthServiceServer(s, srv)
+
+	slog.Info("auth service listening", "addr", cfg.Auth.Addr, "redis", cfg.Redis.Addr)
+	if err := s.Serve(lis); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -21,18 +21,168 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
-// ******* LLM *******
-type ChatRequest struct {
+// 过滤方向：用户输入与模型回复各自有策略（规则的 direction、规则文件的 output 段）
+type FilterDirection int32
+
+const (
+	FilterDirection_INPUT  FilterDirection = 0
+	FilterDirection_OUTPUT FilterDirection = 1
+)
+
+// Enum value maps for FilterDirection.
+var (
+	FilterDirection_name = map[int32]string{
+		0: "INPUT",
+		1: "OUTPUT",
+	}
+	FilterDirection_value = map[string]int32{
+		"INPUT":  0,
+		"OUTPUT": 1,
+	}
+)
+
+func (x FilterDirection) Enum() *FilterDirection {
+	p := new(FilterDirection)
+	*p = x
+	return p
+}
+
+func (x FilterDirection) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FilterDirection) Descriptor() protoreflect.EnumDescriptor {
+	return file_chat_proto_enumTypes[0].Descriptor()
+}
+
+func (FilterDirection) Type() protoreflect.EnumType {
+	return &file_chat_proto_enumTypes[0]
+}
+
+func (x FilterDirection) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FilterDirection.Descriptor instead.
+func (FilterDirection) EnumDescriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{0}
+}
+
+// 翻页方向：OLDER 从游标往更早翻（最近在前），NEWER 从游标往更新翻（时间顺序）
+type ListDirection int32
+
+const (
+	ListDirection_OLDER ListDirection = 0
+	ListDirection_NEWER ListDirection = 1
+)
+
+// Enum value maps for ListDirection.
+var (
+	ListDirection_name = map[int32]string{
+		0: "OLDER",
+		1: "NEWER",
+	}
+	ListDirection_value = map[string]int32{
+		"OLDER": 0,
+		"NEWER": 1,
+	}
+)
+
+func (x ListDirection) Enum() *ListDirection {
+	p := new(ListDirection)
+	*p = x
+	return p
+}
+
+func (x ListDirection) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ListDirection) Descriptor() protoreflect.EnumDescriptor {
+	return file_chat_proto_enumTypes[1].Descriptor()
+}
+
+func (ListDirection) Type() protoreflect.EnumType {
+	return &file_chat_proto_enumTypes[1]
+}
+
+func (x ListDirection) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ListDirection.Descriptor instead.
+func (ListDirection) EnumDescriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{1}
+}
+
+// 一条上下文消息（role: user / assistant；system 指令不从上下文传入）
+type ChatMessage struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Role          string                 `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
 	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
+func (x *ChatMessage) Reset() {
+	*x = ChatMessage{}
+	mi := &file_chat_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessage) ProtoMessage() {}
+
+func (x *ChatMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessage.ProtoReflect.Descriptor instead.
+func (*ChatMessage) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ChatMessage) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *ChatMessage) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type ChatRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Text   string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	// 此前的对话轮次（按时间顺序，最早在前）；llmserver 会按 token 预算裁剪
+	History []*ChatMessage `protobuf:"bytes,3,rep,name=history,proto3" json:"history,omitempty"`
+	// 可选：指定模型（须在服务端白名单内）；为空时使用默认模型，失败按回退链依次尝试
+	Model         string `protobuf:"bytes,4,opt,name=model,proto3" json:"model,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
 func (x *ChatRequest) Reset() {
 	*x = ChatRequest{}
-	mi := &file_chat_proto_msgTypes[0]
+	mi := &file_chat_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -44,7 +194,7 @@ func (x *ChatRequest) String() string {
 func (*ChatRequest) ProtoMessage() {}
 
 func (x *ChatRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[0]
+	mi := &file_chat_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -57,7 +207,7 @@ func (x *ChatRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChatRequest.ProtoReflect.Descriptor instead.
 func (*ChatRequest) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{0}
+	return file_chat_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *ChatRequest) GetUserId() string {
@@ -74,6 +224,20 @@ func (x *ChatRequest) GetText() string {
 	return ""
 }
 
+func (x *ChatRequest) GetHistory() []*ChatMessage {
+	if x != nil {
+		return x.History
+	}
+	return nil
+}
+
+func (x *ChatRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
 type ChatResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	Reply string                 `protobuf:"bytes,1,opt,name=reply,proto3" json:"reply,omitempty"`
@@ -81,25 +245,3111 @@ type ChatResponse struct {
 	PromptTokens     int32 `protobuf:"varint,2,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
 	CompletionTokens int32 `protobuf:"varint,3,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
 	TotalTokens      int32 `protobuf:"varint,4,opt,name=total_tokens,json=totalTokens,proto3" json:"total_tokens,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	// 实际作答的模型（可能是回退后的模型）
+	Model         string `protobuf:"bytes,5,opt,name=model,proto3" json:"model,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatResponse) Reset() {
+	*x = ChatResponse{}
+	mi := &file_chat_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatResponse) ProtoMessage() {}
+
+func (x *ChatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatResponse.ProtoReflect.Descriptor instead.
+func (*ChatResponse) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ChatResponse) GetReply() string {
+	if x != nil {
+		return x.Reply
+	}
+	return ""
+}
+
+func (x *ChatResponse) GetPromptTokens() int32 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *ChatResponse) GetCompletionTokens() int32 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *ChatResponse) GetTotalTokens() int32 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
+func (x *ChatResponse) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+// 流式输出：逐段返回增量文本；最后一帧 done=true 并携带真实 token 用量
+type ChatChunk struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Delta            string                 `protobuf:"bytes,1,opt,name=delta,proto3" json:"delta,omitempty"`
+	Done             bool                   `protobuf:"varint,2,opt,name=done,proto3" json:"done,omitempty"`
+	PromptTokens     int32                  `protobuf:"varint,3,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	CompletionTokens int32                  `protobuf:"varint,4,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	TotalTokens      int32                  `protobuf:"varint,5,opt,name=total_tokens,json=totalTokens,proto3" json:"total_tokens,omitempty"`
+	// 实际作答的模型，随结束帧返回
+	Model         string `protobuf:"bytes,6,opt,name=model,proto3" json:"model,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatChunk) Reset() {
+	*x = ChatChunk{}
+	mi := &file_chat_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatChunk) ProtoMessage() {}
+
+func (x *ChatChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatChunk.ProtoReflect.Descriptor instead.
+func (*ChatChunk) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ChatChunk) GetDelta() string {
+	if x != nil {
+		return x.Delta
+	}
+	return ""
+}
+
+func (x *ChatChunk) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *ChatChunk) GetPromptTokens() int32 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *ChatChunk) GetCompletionTokens() int32 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *ChatChunk) GetTotalTokens() int32 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
+func (x *ChatChunk) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+// user_id 只用于记录拦截事件，不影响判定。
+// partial 表示流式回复中途的审核（之后还会对全文再审一次）：照常判定与记录拦截，
+// 但不计个人敏感信息、遮盖与影子规则的指标和日志，由最后一次审核统一记，避免同一处命中被重复计数。
+// pii_offsets 为同一请求里其他文本（上下文）已经用掉的占位符编号：标签（如 PHONE）→ 最大编号，
+// 新占位符从下一个编号开始，同一请求里不同的值不会得到同一个占位符。
+// history 表示这段文本是请求体显式给出的上下文：照常判定与评分，但不计入 filter_injection_score，
+// 评分分布每个请求只记提问本身一次
+type FilterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Direction     FilterDirection        `protobuf:"varint,3,opt,name=direction,proto3,enum=chat.FilterDirection" json:"direction,omitempty"`
+	Partial       bool                   `protobuf:"varint,4,opt,name=partial,proto3" json:"partial,omitempty"`
+	PiiOffsets    map[string]int32       `protobuf:"bytes,5,rep,name=pii_offsets,json=piiOffsets,proto3" json:"pii_offsets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	History       bool                   `protobuf:"varint,6,opt,name=history,proto3" json:"history,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FilterRequest) Reset() {
+	*x = FilterRequest{}
+	mi := &file_chat_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilterRequest) ProtoMessage() {}
+
+func (x *FilterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilterRequest.ProtoReflect.Descriptor instead.
+func (*FilterRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FilterRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *FilterRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *FilterRequest) GetDirection() FilterDirection {
+	if x != nil {
+		return x.Direction
+	}
+	return FilterDirection_INPUT
+}
+
+func (x *FilterRequest) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
+func (x *FilterRequest) GetPiiOffsets() map[string]int32 {
+	if x != nil {
+		return x.PiiOffsets
+	}
+	return nil
+}
+
+func (x *FilterRequest) GetHistory() bool {
+	if x != nil {
+		return x.History
+	}
+	return false
+}
+
+// 一次规则命中；[start, end) 为原文（FilterRequest.text）里的 Unicode 码点下标
+type FilterMatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RuleId        string                 `protobuf:"bytes,1,opt,name=rule_id,json=ruleId,proto3" json:"rule_id,omitempty"`
+	Category      string                 `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"` // profanity | hate | sexual | violence | self_harm | illegal | pii | injection | spam | other
+	Severity      string                 `protobuf:"bytes,3,opt,name=severity,proto3" json:"severity,omitempty"` // low | medium | high | critical
+	Start         int32                  `protobuf:"varint,4,opt,name=start,proto3" json:"start,omitempty"`
+	End           int32                  `protobuf:"varint,5,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FilterMatch) Reset() {
+	*x = FilterMatch{}
+	mi := &file_chat_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilterMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilterMatch) ProtoMessage() {}
+
+func (x *FilterMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilterMatch.ProtoReflect.Descriptor instead.
+func (*FilterMatch) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *FilterMatch) GetRuleId() string {
+	if x != nil {
+		return x.RuleId
+	}
+	return ""
+}
+
+func (x *FilterMatch) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *FilterMatch) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *FilterMatch) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *FilterMatch) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+// 一处按策略遮盖的个人敏感信息；[start, end) 为原文里的码点下标。
+// value 只在策略允许回填（restore）时返回，网关在本次请求内用它把回复里的占位符换回原值
+type PiiEntity struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`               // phone | email | cn_id | bank_card | api_key
+	Placeholder   string                 `protobuf:"bytes,2,opt,name=placeholder,proto3" json:"placeholder,omitempty"` // cleaned 里替换成的占位符，如 [PHONE_1]；同一值复用同一占位符
+	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Start         int32                  `protobuf:"varint,4,opt,name=start,proto3" json:"start,omitempty"`
+	End           int32                  `protobuf:"varint,5,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PiiEntity) Reset() {
+	*x = PiiEntity{}
+	mi := &file_chat_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PiiEntity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PiiEntity) ProtoMessage() {}
+
+func (x *PiiEntity) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PiiEntity.ProtoReflect.Descriptor instead.
+func (*PiiEntity) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PiiEntity) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *PiiEntity) GetPlaceholder() string {
+	if x != nil {
+		return x.Placeholder
+	}
+	return ""
+}
+
+func (x *PiiEntity) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *PiiEntity) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *PiiEntity) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+// 提示词注入评分里触发的一类信号；[start, end) 为它在原文里第一处的码点下标
+type InjectionSignal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Signal        string                 `protobuf:"bytes,1,opt,name=signal,proto3" json:"signal,omitempty"` // override | exfiltration | jailbreak | role_marker | encoded | hidden | 规则文件自定义的
+	Weight        float32                `protobuf:"fixed32,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	Start         int32                  `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`
+	End           int32                  `protobuf:"varint,4,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InjectionSignal) Reset() {
+	*x = InjectionSignal{}
+	mi := &file_chat_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InjectionSignal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InjectionSignal) ProtoMessage() {}
+
+func (x *InjectionSignal) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InjectionSignal.ProtoReflect.Descriptor instead.
+func (*InjectionSignal) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *InjectionSignal) GetSignal() string {
+	if x != nil {
+		return x.Signal
+	}
+	return ""
+}
+
+func (x *InjectionSignal) GetWeight() float32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *InjectionSignal) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *InjectionSignal) GetEnd() int32 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+// ruleset_version 为做出判定的规则集版本，用于审计“哪一版策略拦截了什么”；
+// category/severity 取 matches 里最严重的一条，放行时为空；
+// 命中 mask 策略的个人敏感信息在 cleaned 里已替换为占位符，明细见 pii；拦截时 cleaned 为空。
+// OUTPUT 方向：cleaned 保留原有空白，redact 的命中逐字替换成 *（长度不变）；allowed 为 true 时 matches 即被遮盖之处。
+// INPUT 方向另有提示词注入评分：injection_score 为 0–1 的风险分，达到规则文件的 injection.threshold 时
+// injection_suspected 为 true；它不影响 allowed，拦截、警告还是只做标记由网关按租户策略决定
+type FilterReply struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Allowed            bool                   `protobuf:"varint,1,opt,name=allowed,proto3" json:"allowed,omitempty"`
+	Cleaned            string                 `protobuf:"bytes,2,opt,name=cleaned,proto3" json:"cleaned,omitempty"`
+	RulesetVersion     string                 `protobuf:"bytes,3,opt,name=ruleset_version,json=rulesetVersion,proto3" json:"ruleset_version,omitempty"`
+	Matches            []*FilterMatch         `protobuf:"bytes,4,rep,name=matches,proto3" json:"matches,omitempty"`
+	Category           string                 `protobuf:"bytes,5,opt,name=category,proto3" json:"category,omitempty"`
+	Severity           string                 `protobuf:"bytes,6,opt,name=severity,proto3" json:"severity,omitempty"`
+	Pii                []*PiiEntity           `protobuf:"bytes,7,rep,name=pii,proto3" json:"pii,omitempty"`
+	InjectionScore     float32                `protobuf:"fixed32,8,opt,name=injection_score,json=injectionScore,proto3" json:"injection_score,omitempty"`
+	InjectionSuspected bool                   `protobuf:"varint,9,opt,name=injection_suspected,json=injectionSuspected,proto3" json:"injection_suspected,omitempty"`
+	InjectionSignals   []*InjectionSignal     `protobuf:"bytes,10,rep,name=injection_signals,json=injectionSignals,proto3" json:"injection_signals,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *FilterReply) Reset() {
+	*x = FilterReply{}
+	mi := &file_chat_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilterReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilterReply) ProtoMessage() {}
+
+func (x *FilterReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilterReply.ProtoReflect.Descriptor instead.
+func (*FilterReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FilterReply) GetAllowed() bool {
+	if x != nil {
+		return x.Allowed
+	}
+	return false
+}
+
+func (x *FilterReply) GetCleaned() string {
+	if x != nil {
+		return x.Cleaned
+	}
+	return ""
+}
+
+func (x *FilterReply) GetRulesetVersion() string {
+	if x != nil {
+		return x.RulesetVersion
+	}
+	return ""
+}
+
+func (x *FilterReply) GetMatches() []*FilterMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+func (x *FilterReply) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *FilterReply) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *FilterReply) GetPii() []*PiiEntity {
+	if x != nil {
+		return x.Pii
+	}
+	return nil
+}
+
+func (x *FilterReply) GetInjectionScore() float32 {
+	if x != nil {
+		return x.InjectionScore
+	}
+	return 0
+}
+
+func (x *FilterReply) GetInjectionSuspected() bool {
+	if x != nil {
+		return x.InjectionSuspected
+	}
+	return false
+}
+
+func (x *FilterReply) GetInjectionSignals() []*InjectionSignal {
+	if x != nil {
+		return x.InjectionSignals
+	}
+	return nil
+}
+
+// 被拦截的请求，按时间倒序供人工复核；id 为 Redis Stream 的条目 ID
+type BlockedEvent struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	CreatedAt      int64                  `protobuf:"varint,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // unix 毫秒
+	UserId         string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	RequestId      string                 `protobuf:"bytes,4,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	RulesetVersion string                 `protobuf:"bytes,5,opt,name=ruleset_version,json=rulesetVersion,proto3" json:"ruleset_version,omitempty"`
+	Category       string                 `protobuf:"bytes,6,opt,name=category,proto3" json:"category,omitempty"`
+	Severity       string                 `protobuf:"bytes,7,opt,name=severity,proto3" json:"severity,omitempty"`
+	Text           string                 `protobuf:"bytes,8,opt,name=text,proto3" json:"text,omitempty"` // 超长时截断
+	Matches        []*FilterMatch         `protobuf:"bytes,9,rep,name=matches,proto3" json:"matches,omitempty"`
+	Direction      FilterDirection        `protobuf:"varint,10,opt,name=direction,proto3,enum=chat.FilterDirection" json:"direction,omitempty"` // OUTPUT 时 text 为被拦截的模型回复
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BlockedEvent) Reset() {
+	*x = BlockedEvent{}
+	mi := &file_chat_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BlockedEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockedEvent) ProtoMessage() {}
+
+func (x *BlockedEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockedEvent.ProtoReflect.Descriptor instead.
+func (*BlockedEvent) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *BlockedEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BlockedEvent) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *BlockedEvent) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *BlockedEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *BlockedEvent) GetRulesetVersion() string {
+	if x != nil {
+		return x.RulesetVersion
+	}
+	return ""
+}
+
+func (x *BlockedEvent) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *BlockedEvent) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *BlockedEvent) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *BlockedEvent) GetMatches() []*FilterMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+func (x *BlockedEvent) GetDirection() FilterDirection {
+	if x != nil {
+		return x.Direction
+	}
+	return FilterDirection_INPUT
+}
+
+// tenant_id 非空时只返回该租户用户（user_id 形如 <tenant_id>/…）的事件；cursor 为上一页的 next_cursor
+type ListBlockedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TenantId      string                 `protobuf:"bytes,1,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Cursor        string                 `protobuf:"bytes,3,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBlockedRequest) Reset() {
+	*x = ListBlockedRequest{}
+	mi := &file_chat_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBlockedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBlockedRequest) ProtoMessage() {}
+
+func (x *ListBlockedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBlockedRequest.ProtoReflect.Descriptor instead.
+func (*ListBlockedRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListBlockedRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+func (x *ListBlockedRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListBlockedRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type ListBlockedReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*BlockedEvent        `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBlockedReply) Reset() {
+	*x = ListBlockedReply{}
+	mi := &file_chat_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBlockedReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBlockedReply) ProtoMessage() {}
+
+func (x *ListBlockedReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBlockedReply.ProtoReflect.Descriptor instead.
+func (*ListBlockedReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListBlockedReply) GetEvents() []*BlockedEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *ListBlockedReply) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+// 网关按租户策略拦下 Filter 放行的文本（如疑似提示词注入）时补记拦截事件，与 Filter 拦截的事件一起复核。
+// created_at、request_id 为空时由 filterserver 填上；text 里的个人敏感信息由 filterserver 遮盖（matches 的位置不变）
+type RecordBlockedReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordBlockedReply) Reset() {
+	*x = RecordBlockedReply{}
+	mi := &file_chat_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordBlockedReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordBlockedReply) ProtoMessage() {}
+
+func (x *RecordBlockedReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordBlockedReply.ProtoReflect.Descriptor instead.
+func (*RecordBlockedReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RecordBlockedReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+// 试运行：用候选规则（规则文件的 YAML 全文，为空时用当前规则）判定一批文本，与当前规则的结果对比，不记录拦截事件。
+// promote_shadow 为 true 时候选规则里 mode: shadow 的规则按 enforce 计算，用来预估“转正”之后的拦截率
+type DryRunRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Policy           string                 `protobuf:"bytes,1,opt,name=policy,proto3" json:"policy,omitempty"`
+	Texts            []string               `protobuf:"bytes,2,rep,name=texts,proto3" json:"texts,omitempty"` // 最多 1000 条
+	Direction        FilterDirection        `protobuf:"varint,3,opt,name=direction,proto3,enum=chat.FilterDirection" json:"direction,omitempty"`
+	PromoteShadow    bool                   `protobuf:"varint,4,opt,name=promote_shadow,json=promoteShadow,proto3" json:"promote_shadow,omitempty"`
+	CandidateVersion string                 `protobuf:"bytes,5,opt,name=candidate_version,json=candidateVersion,proto3" json:"candidate_version,omitempty"` // 代替 policy：用 MySQL 里保存的某个规则版本（见 FilterAdminService）
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *DryRunRequest) Reset() {
+	*x = DryRunRequest{}
+	mi := &file_chat_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DryRunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DryRunRequest) ProtoMessage() {}
+
+func (x *DryRunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DryRunRequest.ProtoReflect.Descriptor instead.
+func (*DryRunRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DryRunRequest) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *DryRunRequest) GetTexts() []string {
+	if x != nil {
+		return x.Texts
+	}
+	return nil
+}
+
+func (x *DryRunRequest) GetDirection() FilterDirection {
+	if x != nil {
+		return x.Direction
+	}
+	return FilterDirection_INPUT
+}
+
+func (x *DryRunRequest) GetPromoteShadow() bool {
+	if x != nil {
+		return x.PromoteShadow
+	}
+	return false
+}
+
+func (x *DryRunRequest) GetCandidateVersion() string {
+	if x != nil {
+		return x.CandidateVersion
+	}
+	return ""
+}
+
+// 一条文本的试运行结果；category/severity/matches 为候选规则的判定（未拦截时 matches 为空）
+type DryRunResult struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Index              int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"` // 在 DryRunRequest.texts 里的下标
+	CurrentBlocked     bool                   `protobuf:"varint,2,opt,name=current_blocked,json=currentBlocked,proto3" json:"current_blocked,omitempty"`
+	CandidateBlocked   bool                   `protobuf:"varint,3,opt,name=candidate_blocked,json=candidateBlocked,proto3" json:"candidate_blocked,omitempty"`
+	Category           string                 `protobuf:"bytes,4,opt,name=category,proto3" json:"category,omitempty"`
+	Severity           string                 `protobuf:"bytes,5,opt,name=severity,proto3" json:"severity,omitempty"`
+	Matches            []*FilterMatch         `protobuf:"bytes,6,rep,name=matches,proto3" json:"matches,omitempty"`
+	InjectionScore     float32                `protobuf:"fixed32,7,opt,name=injection_score,json=injectionScore,proto3" json:"injection_score,omitempty"` // 候选规则下的提示词注入评分（INPUT）
+	InjectionSuspected bool                   `protobuf:"varint,8,opt,name=injection_suspected,json=injectionSuspected,proto3" json:"injection_suspected,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *DryRunResult) Reset() {
+	*x = DryRunResult{}
+	mi := &file_chat_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DryRunResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DryRunResult) ProtoMessage() {}
+
+func (x *DryRunResult) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DryRunResult.ProtoReflect.Descriptor instead.
+func (*DryRunResult) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DryRunResult) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *DryRunResult) GetCurrentBlocked() bool {
+	if x != nil {
+		return x.CurrentBlocked
+	}
+	return false
+}
+
+func (x *DryRunResult) GetCandidateBlocked() bool {
+	if x != nil {
+		return x.CandidateBlocked
+	}
+	return false
+}
+
+func (x *DryRunResult) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *DryRunResult) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *DryRunResult) GetMatches() []*FilterMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+func (x *DryRunResult) GetInjectionScore() float32 {
+	if x != nil {
+		return x.InjectionScore
+	}
+	return 0
+}
+
+func (x *DryRunResult) GetInjectionSuspected() bool {
+	if x != nil {
+		return x.InjectionSuspected
+	}
+	return false
+}
+
+// newly_blocked / newly_allowed：候选规则相对当前规则多拦截 / 少拦截的条数；candidate_categories 为候选规则拦截的条数按分类统计
+type DryRunReply struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	CurrentVersion      string                 `protobuf:"bytes,1,opt,name=current_version,json=currentVersion,proto3" json:"current_version,omitempty"`
+	CandidateVersion    string                 `protobuf:"bytes,2,opt,name=candidate_version,json=candidateVersion,proto3" json:"candidate_version,omitempty"`
+	Total               int32                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+	CurrentBlocked      int32                  `protobuf:"varint,4,opt,name=current_blocked,json=currentBlocked,proto3" json:"current_blocked,omitempty"`
+	CandidateBlocked    int32                  `protobuf:"varint,5,opt,name=candidate_blocked,json=candidateBlocked,proto3" json:"candidate_blocked,omitempty"`
+	NewlyBlocked        int32                  `protobuf:"varint,6,opt,name=newly_blocked,json=newlyBlocked,proto3" json:"newly_blocked,omitempty"`
+	NewlyAllowed        int32                  `protobuf:"varint,7,opt,name=newly_allowed,json=newlyAllowed,proto3" json:"newly_allowed,omitempty"`
+	CandidateCategories map[string]int32       `protobuf:"bytes,8,rep,name=candidate_categories,json=candidateCategories,proto3" json:"candidate_categories,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Results             []*DryRunResult        `protobuf:"bytes,9,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *DryRunReply) Reset() {
+	*x = DryRunReply{}
+	mi := &file_chat_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DryRunReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DryRunReply) ProtoMessage() {}
+
+func (x *DryRunReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DryRunReply.ProtoReflect.Descriptor instead.
+func (*DryRunReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DryRunReply) GetCurrentVersion() string {
+	if x != nil {
+		return x.CurrentVersion
+	}
+	return ""
+}
+
+func (x *DryRunReply) GetCandidateVersion() string {
+	if x != nil {
+		return x.CandidateVersion
+	}
+	return ""
+}
+
+func (x *DryRunReply) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *DryRunReply) GetCurrentBlocked() int32 {
+	if x != nil {
+		return x.CurrentBlocked
+	}
+	return 0
+}
+
+func (x *DryRunReply) GetCandidateBlocked() int32 {
+	if x != nil {
+		return x.CandidateBlocked
+	}
+	return 0
+}
+
+func (x *DryRunReply) GetNewlyBlocked() int32 {
+	if x != nil {
+		return x.NewlyBlocked
+	}
+	return 0
+}
+
+func (x *DryRunReply) GetNewlyAllowed() int32 {
+	if x != nil {
+		return x.NewlyAllowed
+	}
+	return 0
+}
+
+func (x *DryRunReply) GetCandidateCategories() map[string]int32 {
+	if x != nil {
+		return x.CandidateCategories
+	}
+	return nil
+}
+
+func (x *DryRunReply) GetResults() []*DryRunResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// 规则管理（filter.store=mysql 时可用）：每次增删改规则都在最新版本的基础上生成一个新版本（完整的规则文件，
+// 版本号形如 v12），激活（ActivatePolicy）后经 Redis pub/sub 通知所有 filterserver 副本切换；所有改动写审计日志。
+// 字段含义同规则文件（configs/filter_rules.yaml）
+type FilterRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Category      string                 `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
+	Severity      string                 `protobuf:"bytes,3,opt,name=severity,proto3" json:"severity,omitempty"`
+	Words         []string               `protobuf:"bytes,4,rep,name=words,proto3" json:"words,omitempty"`
+	Match         string                 `protobuf:"bytes,5,opt,name=match,proto3" json:"match,omitempty"` // word | substring
+	Regex         string                 `protobuf:"bytes,6,opt,name=regex,proto3" json:"regex,omitempty"`
+	Direction     string                 `protobuf:"bytes,7,opt,name=direction,proto3" json:"direction,omitempty"`                           // input | output | both
+	OutputAction  string                 `protobuf:"bytes,8,opt,name=output_action,json=outputAction,proto3" json:"output_action,omitempty"` // block | redact
+	Mode          string                 `protobuf:"bytes,9,opt,name=mode,proto3" json:"mode,omitempty"`                                     // enforce | shadow
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FilterRule) Reset() {
+	*x = FilterRule{}
+	mi := &file_chat_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilterRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilterRule) ProtoMessage() {}
+
+func (x *FilterRule) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilterRule.ProtoReflect.Descriptor instead.
+func (*FilterRule) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *FilterRule) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *FilterRule) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *FilterRule) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *FilterRule) GetWords() []string {
+	if x != nil {
+		return x.Words
+	}
+	return nil
+}
+
+func (x *FilterRule) GetMatch() string {
+	if x != nil {
+		return x.Match
+	}
+	return ""
+}
+
+func (x *FilterRule) GetRegex() string {
+	if x != nil {
+		return x.Regex
+	}
+	return ""
+}
+
+func (x *FilterRule) GetDirection() string {
+	if x != nil {
+		return x.Direction
+	}
+	return ""
+}
+
+func (x *FilterRule) GetOutputAction() string {
+	if x != nil {
+		return x.OutputAction
+	}
+	return ""
+}
+
+func (x *FilterRule) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+type FilterPolicy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Parent        string                 `protobuf:"bytes,2,opt,name=parent,proto3" json:"parent,omitempty"` // 基于哪个版本改出来的；从规则文件导入时为空
+	CreatedBy     string                 `protobuf:"bytes,3,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // unix 秒
+	Comment       string                 `protobuf:"bytes,5,opt,name=comment,proto3" json:"comment,omitempty"`
+	Active        bool                   `protobuf:"varint,6,opt,name=active,proto3" json:"active,omitempty"`
+	RuleCount     int32                  `protobuf:"varint,7,opt,name=rule_count,json=ruleCount,proto3" json:"rule_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FilterPolicy) Reset() {
+	*x = FilterPolicy{}
+	mi := &file_chat_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilterPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilterPolicy) ProtoMessage() {}
+
+func (x *FilterPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilterPolicy.ProtoReflect.Descriptor instead.
+func (*FilterPolicy) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *FilterPolicy) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *FilterPolicy) GetParent() string {
+	if x != nil {
+		return x.Parent
+	}
+	return ""
+}
+
+func (x *FilterPolicy) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *FilterPolicy) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *FilterPolicy) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+func (x *FilterPolicy) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *FilterPolicy) GetRuleCount() int32 {
+	if x != nil {
+		return x.RuleCount
+	}
+	return 0
+}
+
+// 按创建时间倒序；latest 为最新版本（编辑的基础），active 为当前生效的版本（为空表示仍在用规则文件）
+type ListPoliciesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPoliciesRequest) Reset() {
+	*x = ListPoliciesRequest{}
+	mi := &file_chat_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPoliciesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPoliciesRequest) ProtoMessage() {}
+
+func (x *ListPoliciesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPoliciesRequest.ProtoReflect.Descriptor instead.
+func (*ListPoliciesRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListPoliciesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListPoliciesReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Policies      []*FilterPolicy        `protobuf:"bytes,1,rep,name=policies,proto3" json:"policies,omitempty"`
+	Active        string                 `protobuf:"bytes,2,opt,name=active,proto3" json:"active,omitempty"`
+	Latest        string                 `protobuf:"bytes,3,opt,name=latest,proto3" json:"latest,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPoliciesReply) Reset() {
+	*x = ListPoliciesReply{}
+	mi := &file_chat_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPoliciesReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPoliciesReply) ProtoMessage() {}
+
+func (x *ListPoliciesReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPoliciesReply.ProtoReflect.Descriptor instead.
+func (*ListPoliciesReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListPoliciesReply) GetPolicies() []*FilterPolicy {
+	if x != nil {
+		return x.Policies
+	}
+	return nil
+}
+
+func (x *ListPoliciesReply) GetActive() string {
+	if x != nil {
+		return x.Active
+	}
+	return ""
+}
+
+func (x *ListPoliciesReply) GetLatest() string {
+	if x != nil {
+		return x.Latest
+	}
+	return ""
+}
+
+// version 为空时取最新版本
+type ListRulesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRulesRequest) Reset() {
+	*x = ListRulesRequest{}
+	mi := &file_chat_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRulesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRulesRequest) ProtoMessage() {}
+
+func (x *ListRulesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRulesRequest.ProtoReflect.Descriptor instead.
+func (*ListRulesRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListRulesRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type ListRulesReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Rules         []*FilterRule          `protobuf:"bytes,2,rep,name=rules,proto3" json:"rules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRulesReply) Reset() {
+	*x = ListRulesReply{}
+	mi := &file_chat_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRulesReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRulesReply) ProtoMessage() {}
+
+func (x *ListRulesReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRulesReply.ProtoReflect.Descriptor instead.
+func (*ListRulesReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListRulesReply) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ListRulesReply) GetRules() []*FilterRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+// actor 为操作人（网关取管理员 key 的 user_id），actor_key 为 key id；
+// base_version 非空时要求它仍是最新版本，否则返回 FailedPrecondition（避免覆盖别人刚做的改动）
+type PutRuleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          *FilterRule            `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	Actor         string                 `protobuf:"bytes,2,opt,name=actor,proto3" json:"actor,omitempty"`
+	ActorKey      string                 `protobuf:"bytes,3,opt,name=actor_key,json=actorKey,proto3" json:"actor_key,omitempty"`
+	Comment       string                 `protobuf:"bytes,4,opt,name=comment,proto3" json:"comment,omitempty"`
+	BaseVersion   string                 `protobuf:"bytes,5,opt,name=base_version,json=baseVersion,proto3" json:"base_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutRuleRequest) Reset() {
+	*x = PutRuleRequest{}
+	mi := &file_chat_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutRuleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutRuleRequest) ProtoMessage() {}
+
+func (x *PutRuleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutRuleRequest.ProtoReflect.Descriptor instead.
+func (*PutRuleRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *PutRuleRequest) GetRule() *FilterRule {
+	if x != nil {
+		return x.Rule
+	}
+	return nil
+}
+
+func (x *PutRuleRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *PutRuleRequest) GetActorKey() string {
+	if x != nil {
+		return x.ActorKey
+	}
+	return ""
+}
+
+func (x *PutRuleRequest) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+func (x *PutRuleRequest) GetBaseVersion() string {
+	if x != nil {
+		return x.BaseVersion
+	}
+	return ""
+}
+
+type DeleteRuleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Actor         string                 `protobuf:"bytes,2,opt,name=actor,proto3" json:"actor,omitempty"`
+	ActorKey      string                 `protobuf:"bytes,3,opt,name=actor_key,json=actorKey,proto3" json:"actor_key,omitempty"`
+	Comment       string                 `protobuf:"bytes,4,opt,name=comment,proto3" json:"comment,omitempty"`
+	BaseVersion   string                 `protobuf:"bytes,5,opt,name=base_version,json=baseVersion,proto3" json:"base_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRuleRequest) Reset() {
+	*x = DeleteRuleRequest{}
+	mi := &file_chat_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRuleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRuleRequest) ProtoMessage() {}
+
+func (x *DeleteRuleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRuleRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRuleRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DeleteRuleRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteRuleRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *DeleteRuleRequest) GetActorKey() string {
+	if x != nil {
+		return x.ActorKey
+	}
+	return ""
+}
+
+func (x *DeleteRuleRequest) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+func (x *DeleteRuleRequest) GetBaseVersion() string {
+	if x != nil {
+		return x.BaseVersion
+	}
+	return ""
+}
+
+type ActivatePolicyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Actor         string                 `protobuf:"bytes,2,opt,name=actor,proto3" json:"actor,omitempty"`
+	ActorKey      string                 `protobuf:"bytes,3,opt,name=actor_key,json=actorKey,proto3" json:"actor_key,omitempty"`
+	Comment       string                 `protobuf:"bytes,4,opt,name=comment,proto3" json:"comment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ActivatePolicyRequest) Reset() {
+	*x = ActivatePolicyRequest{}
+	mi := &file_chat_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ActivatePolicyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActivatePolicyRequest) ProtoMessage() {}
+
+func (x *ActivatePolicyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActivatePolicyRequest.ProtoReflect.Descriptor instead.
+func (*ActivatePolicyRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ActivatePolicyRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ActivatePolicyRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *ActivatePolicyRequest) GetActorKey() string {
+	if x != nil {
+		return x.ActorKey
+	}
+	return ""
+}
+
+func (x *ActivatePolicyRequest) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+// 审计日志；before/after 为改动前后的规则（FilterRule 的 JSON），激活时为前后的版本号
+type FilterAuditEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // unix 秒
+	Actor         string                 `protobuf:"bytes,3,opt,name=actor,proto3" json:"actor,omitempty"`
+	ActorKey      string                 `protobuf:"bytes,4,opt,name=actor_key,json=actorKey,proto3" json:"actor_key,omitempty"`
+	Action        string                 `protobuf:"bytes,5,opt,name=action,proto3" json:"action,omitempty"`   // rule.create | rule.update | rule.delete | policy.activate
+	Target        string                 `protobuf:"bytes,6,opt,name=target,proto3" json:"target,omitempty"`   // 规则 id 或版本号
+	Version       string                 `protobuf:"bytes,7,opt,name=version,proto3" json:"version,omitempty"` // 改动生成（或激活）的版本
+	Before        string                 `protobuf:"bytes,8,opt,name=before,proto3" json:"before,omitempty"`
+	After         string                 `protobuf:"bytes,9,opt,name=after,proto3" json:"after,omitempty"`
+	Comment       string                 `protobuf:"bytes,10,opt,name=comment,proto3" json:"comment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FilterAuditEntry) Reset() {
+	*x = FilterAuditEntry{}
+	mi := &file_chat_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FilterAuditEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FilterAuditEntry) ProtoMessage() {}
+
+func (x *FilterAuditEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FilterAuditEntry.ProtoReflect.Descriptor instead.
+func (*FilterAuditEntry) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *FilterAuditEntry) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *FilterAuditEntry) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *FilterAuditEntry) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *FilterAuditEntry) GetActorKey() string {
+	if x != nil {
+		return x.ActorKey
+	}
+	return ""
+}
+
+func (x *FilterAuditEntry) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *FilterAuditEntry) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *FilterAuditEntry) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *FilterAuditEntry) GetBefore() string {
+	if x != nil {
+		return x.Before
+	}
+	return ""
+}
+
+func (x *FilterAuditEntry) GetAfter() string {
+	if x != nil {
+		return x.After
+	}
+	return ""
+}
+
+func (x *FilterAuditEntry) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+// target 非空时只看该规则 / 版本的记录；cursor 为上一页的 next_cursor
+type ListAuditRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Cursor        string                 `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Target        string                 `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditRequest) Reset() {
+	*x = ListAuditRequest{}
+	mi := &file_chat_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditRequest) ProtoMessage() {}
+
+func (x *ListAuditRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditRequest.ProtoReflect.Descriptor instead.
+func (*ListAuditRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ListAuditRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListAuditRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListAuditRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type ListAuditReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*FilterAuditEntry    `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditReply) Reset() {
+	*x = ListAuditReply{}
+	mi := &file_chat_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditReply) ProtoMessage() {}
+
+func (x *ListAuditReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditReply.ProtoReflect.Descriptor instead.
+func (*ListAuditReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListAuditReply) GetEntries() []*FilterAuditEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *ListAuditReply) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+// ******* Token *******
+type TokenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Tokens        int32                  `protobuf:"varint,2,opt,name=tokens,proto3" json:"tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenRequest) Reset() {
+	*x = TokenRequest{}
+	mi := &file_chat_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenRequest) ProtoMessage() {}
+
+func (x *TokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenRequest.ProtoReflect.Descriptor instead.
+func (*TokenRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *TokenRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *TokenRequest) GetTokens() int32 {
+	if x != nil {
+		return x.Tokens
+	}
+	return 0
+}
+
+// remaining 为各 token 窗口里最少的剩余量；被拒绝时 window 为拒绝的配额窗口（如 tokens/minute、requests/day），
+// reset_at 为该窗口重置的时间（unix 秒）
+type TokenReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Allowed       bool                   `protobuf:"varint,1,opt,name=allowed,proto3" json:"allowed,omitempty"`
+	Remaining     int64                  `protobuf:"varint,2,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	Window        string                 `protobuf:"bytes,3,opt,name=window,proto3" json:"window,omitempty"`
+	ResetAt       int64                  `protobuf:"varint,4,opt,name=reset_at,json=resetAt,proto3" json:"reset_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TokenReply) Reset() {
+	*x = TokenReply{}
+	mi := &file_chat_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenReply) ProtoMessage() {}
+
+func (x *TokenReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenReply.ProtoReflect.Descriptor instead.
+func (*TokenReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *TokenReply) GetAllowed() bool {
+	if x != nil {
+		return x.Allowed
+	}
+	return false
+}
+
+func (x *TokenReply) GetRemaining() int64 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+func (x *TokenReply) GetWindow() string {
+	if x != nil {
+		return x.Window
+	}
+	return ""
+}
+
+func (x *TokenReply) GetResetAt() int64 {
+	if x != nil {
+		return x.ResetAt
+	}
+	return 0
+}
+
+// 预占：先扣住 tokens，拿到 reservation_id；超过 ttl_seconds 未 commit/release 的预占由服务端自动退回
+type ReserveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Tokens        int32                  `protobuf:"varint,2,opt,name=tokens,proto3" json:"tokens,omitempty"`
+	TtlSeconds    int32                  `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReserveRequest) Reset() {
+	*x = ReserveRequest{}
+	mi := &file_chat_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReserveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReserveRequest) ProtoMessage() {}
+
+func (x *ReserveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReserveRequest.ProtoReflect.Descriptor instead.
+func (*ReserveRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ReserveRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ReserveRequest) GetTokens() int32 {
+	if x != nil {
+		return x.Tokens
+	}
+	return 0
+}
+
+func (x *ReserveRequest) GetTtlSeconds() int32 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type ReserveReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Allowed       bool                   `protobuf:"varint,1,opt,name=allowed,proto3" json:"allowed,omitempty"`
+	Remaining     int64                  `protobuf:"varint,2,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	ReservationId string                 `protobuf:"bytes,3,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	Window        string                 `protobuf:"bytes,4,opt,name=window,proto3" json:"window,omitempty"`
+	ResetAt       int64                  `protobuf:"varint,5,opt,name=reset_at,json=resetAt,proto3" json:"reset_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReserveReply) Reset() {
+	*x = ReserveReply{}
+	mi := &file_chat_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReserveReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReserveReply) ProtoMessage() {}
+
+func (x *ReserveReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReserveReply.ProtoReflect.Descriptor instead.
+func (*ReserveReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ReserveReply) GetAllowed() bool {
+	if x != nil {
+		return x.Allowed
+	}
+	return false
+}
+
+func (x *ReserveReply) GetRemaining() int64 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+func (x *ReserveReply) GetReservationId() string {
+	if x != nil {
+		return x.ReservationId
+	}
+	return ""
+}
+
+func (x *ReserveReply) GetWindow() string {
+	if x != nil {
+		return x.Window
+	}
+	return ""
+}
+
+func (x *ReserveReply) GetResetAt() int64 {
+	if x != nil {
+		return x.ResetAt
+	}
+	return 0
+}
+
+// 确认：按真实用量结算（多退少补）；预占已过期被回收时按 tokens 全额扣减。
+// 同一预占重复确认（重试）为空操作；预占不属于 user_id 时返回 PermissionDenied（Release 同）
+type CommitRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ReservationId string                 `protobuf:"bytes,2,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	Tokens        int32                  `protobuf:"varint,3,opt,name=tokens,proto3" json:"tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitRequest) Reset() {
+	*x = CommitRequest{}
+	mi := &file_chat_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitRequest) ProtoMessage() {}
+
+func (x *CommitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitRequest.ProtoReflect.Descriptor instead.
+func (*CommitRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *CommitRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CommitRequest) GetReservationId() string {
+	if x != nil {
+		return x.ReservationId
+	}
+	return ""
+}
+
+func (x *CommitRequest) GetTokens() int32 {
+	if x != nil {
+		return x.Tokens
+	}
+	return 0
+}
+
+// 释放：退回整笔预占（幂等，重复释放或已过期均为空操作）
+type ReleaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ReservationId string                 `protobuf:"bytes,2,opt,name=reservation_id,json=reservationId,proto3" json:"reservation_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseRequest) Reset() {
+	*x = ReleaseRequest{}
+	mi := &file_chat_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseRequest) ProtoMessage() {}
+
+func (x *ReleaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseRequest.ProtoReflect.Descriptor instead.
+func (*ReleaseRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ReleaseRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ReleaseRequest) GetReservationId() string {
+	if x != nil {
+		return x.ReservationId
+	}
+	return ""
+}
+
+// conversation_id 为空时沿用旧行为：不归属任何会话，按用户整体读写
+type SaveRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Role           string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
+	Text           string                 `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	ConversationId string                 `protobuf:"bytes,4,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SaveRequest) Reset() {
+	*x = SaveRequest{}
+	mi := &file_chat_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SaveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveRequest) ProtoMessage() {}
+
+func (x *SaveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveRequest.ProtoReflect.Descriptor instead.
+func (*SaveRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *SaveRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *SaveRequest) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *SaveRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SaveRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+type SaveReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SaveReply) Reset() {
+	*x = SaveReply{}
+	mi := &file_chat_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SaveReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveReply) ProtoMessage() {}
+
+func (x *SaveReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveReply.ProtoReflect.Descriptor instead.
+func (*SaveReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *SaveReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+type HistoryItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Role          string                 `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Id            string                 `protobuf:"bytes,3,opt,name=id,proto3" json:"id,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryItem) Reset() {
+	*x = HistoryItem{}
+	mi := &file_chat_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryItem) ProtoMessage() {}
+
+func (x *HistoryItem) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryItem.ProtoReflect.Descriptor instead.
+func (*HistoryItem) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *HistoryItem) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *HistoryItem) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *HistoryItem) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *HistoryItem) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+// cursor 为上一页返回的 next_cursor（不透明）；为空表示从最新一条开始
+type ListRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Limit          int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	ConversationId string                 `protobuf:"bytes,3,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	Cursor         string                 `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Direction      ListDirection          `protobuf:"varint,5,opt,name=direction,proto3,enum=chat.ListDirection" json:"direction,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_chat_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ListRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ListRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListRequest) GetDirection() ListDirection {
+	if x != nil {
+		return x.Direction
+	}
+	return ListDirection_OLDER
+}
+
+// next_cursor 为空表示没有更多
+type ListReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*HistoryItem         `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListReply) Reset() {
+	*x = ListReply{}
+	mi := &file_chat_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReply) ProtoMessage() {}
+
+func (x *ListReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReply.ProtoReflect.Descriptor instead.
+func (*ListReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *ListReply) GetItems() []*HistoryItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *ListReply) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+// 会话（时间为 unix 秒）
+type Conversation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     int64                  `protobuf:"varint,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Conversation) Reset() {
+	*x = Conversation{}
+	mi := &file_chat_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Conversation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Conversation) ProtoMessage() {}
+
+func (x *Conversation) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Conversation.ProtoReflect.Descriptor instead.
+func (*Conversation) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *Conversation) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Conversation) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Conversation) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Conversation) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+type CreateConversationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateConversationRequest) Reset() {
+	*x = CreateConversationRequest{}
+	mi := &file_chat_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateConversationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateConversationRequest) ProtoMessage() {}
+
+func (x *CreateConversationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateConversationRequest.ProtoReflect.Descriptor instead.
+func (*CreateConversationRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *CreateConversationRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateConversationRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+type ListConversationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListConversationsRequest) Reset() {
+	*x = ListConversationsRequest{}
+	mi := &file_chat_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListConversationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListConversationsRequest) ProtoMessage() {}
+
+func (x *ListConversationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListConversationsRequest.ProtoReflect.Descriptor instead.
+func (*ListConversationsRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ListConversationsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListConversationsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListConversationsReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Conversations []*Conversation        `protobuf:"bytes,1,rep,name=conversations,proto3" json:"conversations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListConversationsReply) Reset() {
+	*x = ListConversationsReply{}
+	mi := &file_chat_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListConversationsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListConversationsReply) ProtoMessage() {}
+
+func (x *ListConversationsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListConversationsReply.ProtoReflect.Descriptor instead.
+func (*ListConversationsReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *ListConversationsReply) GetConversations() []*Conversation {
+	if x != nil {
+		return x.Conversations
+	}
+	return nil
+}
+
+type RenameConversationRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ConversationId string                 `protobuf:"bytes,2,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	Title          string                 `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RenameConversationRequest) Reset() {
+	*x = RenameConversationRequest{}
+	mi := &file_chat_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenameConversationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenameConversationRequest) ProtoMessage() {}
+
+func (x *RenameConversationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenameConversationRequest.ProtoReflect.Descriptor instead.
+func (*RenameConversationRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *RenameConversationRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RenameConversationRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *RenameConversationRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+type DeleteConversationRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ConversationId string                 `protobuf:"bytes,2,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DeleteConversationRequest) Reset() {
+	*x = DeleteConversationRequest{}
+	mi := &file_chat_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteConversationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteConversationRequest) ProtoMessage() {}
+
+func (x *DeleteConversationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteConversationRequest.ProtoReflect.Descriptor instead.
+func (*DeleteConversationRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *DeleteConversationRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DeleteConversationRequest) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+type DeleteConversationReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteConversationReply) Reset() {
+	*x = DeleteConversationReply{}
+	mi := &file_chat_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteConversationReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteConversationReply) ProtoMessage() {}
+
+func (x *DeleteConversationReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteConversationReply.ProtoReflect.Descriptor instead.
+func (*DeleteConversationReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *DeleteConversationReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
 }
 
-func (x *ChatResponse) Reset() {
-	*x = ChatResponse{}
-	mi := &file_chat_proto_msgTypes[1]
+// API Key：只存 sha256 哈希；明文只在签发/轮换时返回一次。
+// 绑定用户（user_id）或租户（tenant_id，user_id 为空时由调用方在租户内指定终端用户）。
+type ApiKey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Prefix        string                 `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"` // 明文前缀，便于辨认，如 "sk-1a2b3c4"
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	TenantId      string                 `protobuf:"bytes,4,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	Scopes        []string               `protobuf:"bytes,5,rep,name=scopes,proto3" json:"scopes,omitempty"` // chat / history:read / admin
+	Name          string                 `protobuf:"bytes,6,opt,name=name,proto3" json:"name,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	RevokedAt     int64                  `protobuf:"varint,8,opt,name=revoked_at,json=revokedAt,proto3" json:"revoked_at,omitempty"` // 0 表示未吊销
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApiKey) Reset() {
+	*x = ApiKey{}
+	mi := &file_chat_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ChatResponse) String() string {
+func (x *ApiKey) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ChatResponse) ProtoMessage() {}
+func (*ApiKey) ProtoMessage() {}
 
-func (x *ChatResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[1]
+func (x *ApiKey) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -110,107 +3360,89 @@ func (x *ChatResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ChatResponse.ProtoReflect.Descriptor instead.
-func (*ChatResponse) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use ApiKey.ProtoReflect.Descriptor instead.
+func (*ApiKey) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{46}
 }
 
-func (x *ChatResponse) GetReply() string {
+func (x *ApiKey) GetId() string {
 	if x != nil {
-		return x.Reply
+		return x.Id
 	}
 	return ""
 }
 
-func (x *ChatResponse) GetPromptTokens() int32 {
+func (x *ApiKey) GetPrefix() string {
 	if x != nil {
-		return x.PromptTokens
+		return x.Prefix
 	}
-	return 0
+	return ""
 }
 
-func (x *ChatResponse) GetCompletionTokens() int32 {
+func (x *ApiKey) GetUserId() string {
 	if x != nil {
-		return x.CompletionTokens
+		return x.UserId
 	}
-	return 0
+	return ""
 }
 
-func (x *ChatResponse) GetTotalTokens() int32 {
+func (x *ApiKey) GetTenantId() string {
 	if x != nil {
-		return x.TotalTokens
+		return x.TenantId
 	}
-	return 0
-}
-
-// ******* Filter *******
-type FilterRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *FilterRequest) Reset() {
-	*x = FilterRequest{}
-	mi := &file_chat_proto_msgTypes[2]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+	return ""
 }
 
-func (x *FilterRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *ApiKey) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
 }
 
-func (*FilterRequest) ProtoMessage() {}
-
-func (x *FilterRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[2]
+func (x *ApiKey) GetName() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Name
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use FilterRequest.ProtoReflect.Descriptor instead.
-func (*FilterRequest) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{2}
+func (x *ApiKey) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
 }
 
-func (x *FilterRequest) GetText() string {
+func (x *ApiKey) GetRevokedAt() int64 {
 	if x != nil {
-		return x.Text
+		return x.RevokedAt
 	}
-	return ""
+	return 0
 }
 
-type FilterReply struct {
+type AuthenticateRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Allowed       bool                   `protobuf:"varint,1,opt,name=allowed,proto3" json:"allowed,omitempty"`
-	Cleaned       string                 `protobuf:"bytes,2,opt,name=cleaned,proto3" json:"cleaned,omitempty"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *FilterReply) Reset() {
-	*x = FilterReply{}
-	mi := &file_chat_proto_msgTypes[3]
+func (x *AuthenticateRequest) Reset() {
+	*x = AuthenticateRequest{}
+	mi := &file_chat_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *FilterReply) String() string {
+func (x *AuthenticateRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FilterReply) ProtoMessage() {}
+func (*AuthenticateRequest) ProtoMessage() {}
 
-func (x *FilterReply) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[3]
+func (x *AuthenticateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -221,49 +3453,44 @@ func (x *FilterReply) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FilterReply.ProtoReflect.Descriptor instead.
-func (*FilterReply) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{3}
-}
-
-func (x *FilterReply) GetAllowed() bool {
-	if x != nil {
-		return x.Allowed
-	}
-	return false
+// Deprecated: Use AuthenticateRequest.ProtoReflect.Descriptor instead.
+func (*AuthenticateRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{47}
 }
 
-func (x *FilterReply) GetCleaned() string {
+func (x *AuthenticateRequest) GetKey() string {
 	if x != nil {
-		return x.Cleaned
+		return x.Key
 	}
 	return ""
 }
 
-// ******* Token *******
-type TokenRequest struct {
+// tenant_id 非空时只允许管理该租户下的 key（租户管理员）；为空表示全局
+type IssueKeyRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Tokens        int32                  `protobuf:"varint,2,opt,name=tokens,proto3" json:"tokens,omitempty"`
+	TenantId      string                 `protobuf:"bytes,2,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	Scopes        []string               `protobuf:"bytes,3,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *TokenRequest) Reset() {
-	*x = TokenRequest{}
-	mi := &file_chat_proto_msgTypes[4]
+func (x *IssueKeyRequest) Reset() {
+	*x = IssueKeyRequest{}
+	mi := &file_chat_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TokenRequest) String() string {
+func (x *IssueKeyRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TokenRequest) ProtoMessage() {}
+func (*IssueKeyRequest) ProtoMessage() {}
 
-func (x *TokenRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[4]
+func (x *IssueKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -274,48 +3501,62 @@ func (x *TokenRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TokenRequest.ProtoReflect.Descriptor instead.
-func (*TokenRequest) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use IssueKeyRequest.ProtoReflect.Descriptor instead.
+func (*IssueKeyRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{48}
 }
 
-func (x *TokenRequest) GetUserId() string {
+func (x *IssueKeyRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
 	}
 	return ""
 }
 
-func (x *TokenRequest) GetTokens() int32 {
+func (x *IssueKeyRequest) GetTenantId() string {
 	if x != nil {
-		return x.Tokens
+		return x.TenantId
 	}
-	return 0
+	return ""
 }
 
-type TokenReply struct {
+func (x *IssueKeyRequest) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+func (x *IssueKeyRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type IssueKeyReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Allowed       bool                   `protobuf:"varint,1,opt,name=allowed,proto3" json:"allowed,omitempty"`
-	Remaining     int64                  `protobuf:"varint,2,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	Key           *ApiKey                `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Secret        string                 `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *TokenReply) Reset() {
-	*x = TokenReply{}
-	mi := &file_chat_proto_msgTypes[5]
+func (x *IssueKeyReply) Reset() {
+	*x = IssueKeyReply{}
+	mi := &file_chat_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TokenReply) String() string {
+func (x *IssueKeyReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TokenReply) ProtoMessage() {}
+func (*IssueKeyReply) ProtoMessage() {}
 
-func (x *TokenReply) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[5]
+func (x *IssueKeyReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -326,50 +3567,48 @@ func (x *TokenReply) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TokenReply.ProtoReflect.Descriptor instead.
-func (*TokenReply) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use IssueKeyReply.ProtoReflect.Descriptor instead.
+func (*IssueKeyReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{49}
 }
 
-func (x *TokenReply) GetAllowed() bool {
+func (x *IssueKeyReply) GetKey() *ApiKey {
 	if x != nil {
-		return x.Allowed
+		return x.Key
 	}
-	return false
+	return nil
 }
 
-func (x *TokenReply) GetRemaining() int64 {
+func (x *IssueKeyReply) GetSecret() string {
 	if x != nil {
-		return x.Remaining
+		return x.Secret
 	}
-	return 0
+	return ""
 }
 
-// ******* History *******
-type SaveRequest struct {
+type ListKeysRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
-	Text          string                 `protobuf:"bytes,3,opt,name=text,proto3" json:"text,omitempty"`
+	TenantId      string                 `protobuf:"bytes,2,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SaveRequest) Reset() {
-	*x = SaveRequest{}
-	mi := &file_chat_proto_msgTypes[6]
+func (x *ListKeysRequest) Reset() {
+	*x = ListKeysRequest{}
+	mi := &file_chat_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SaveRequest) String() string {
+func (x *ListKeysRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SaveRequest) ProtoMessage() {}
+func (*ListKeysRequest) ProtoMessage() {}
 
-func (x *SaveRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[6]
+func (x *ListKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -380,54 +3619,47 @@ func (x *SaveRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SaveRequest.ProtoReflect.Descriptor instead.
-func (*SaveRequest) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use ListKeysRequest.ProtoReflect.Descriptor instead.
+func (*ListKeysRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{50}
 }
 
-func (x *SaveRequest) GetUserId() string {
+func (x *ListKeysRequest) GetUserId() string {
 	if x != nil {
 		return x.UserId
 	}
 	return ""
 }
 
-func (x *SaveRequest) GetRole() string {
-	if x != nil {
-		return x.Role
-	}
-	return ""
-}
-
-func (x *SaveRequest) GetText() string {
+func (x *ListKeysRequest) GetTenantId() string {
 	if x != nil {
-		return x.Text
+		return x.TenantId
 	}
 	return ""
 }
 
-type SaveReply struct {
+type ListKeysReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Keys          []*ApiKey              `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SaveReply) Reset() {
-	*x = SaveReply{}
-	mi := &file_chat_proto_msgTypes[7]
+func (x *ListKeysReply) Reset() {
+	*x = ListKeysReply{}
+	mi := &file_chat_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SaveReply) String() string {
+func (x *ListKeysReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SaveReply) ProtoMessage() {}
+func (*ListKeysReply) ProtoMessage() {}
 
-func (x *SaveReply) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[7]
+func (x *ListKeysReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -438,41 +3670,41 @@ func (x *SaveReply) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SaveReply.ProtoReflect.Descriptor instead.
-func (*SaveReply) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use ListKeysReply.ProtoReflect.Descriptor instead.
+func (*ListKeysReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{51}
 }
 
-func (x *SaveReply) GetOk() bool {
+func (x *ListKeysReply) GetKeys() []*ApiKey {
 	if x != nil {
-		return x.Ok
+		return x.Keys
 	}
-	return false
+	return nil
 }
 
-type HistoryItem struct {
+type RotateKeyRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Role          string                 `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
-	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TenantId      string                 `protobuf:"bytes,2,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HistoryItem) Reset() {
-	*x = HistoryItem{}
-	mi := &file_chat_proto_msgTypes[8]
+func (x *RotateKeyRequest) Reset() {
+	*x = RotateKeyRequest{}
+	mi := &file_chat_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HistoryItem) String() string {
+func (x *RotateKeyRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HistoryItem) ProtoMessage() {}
+func (*RotateKeyRequest) ProtoMessage() {}
 
-func (x *HistoryItem) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[8]
+func (x *RotateKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -483,48 +3715,48 @@ func (x *HistoryItem) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HistoryItem.ProtoReflect.Descriptor instead.
-func (*HistoryItem) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use RotateKeyRequest.ProtoReflect.Descriptor instead.
+func (*RotateKeyRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{52}
 }
 
-func (x *HistoryItem) GetRole() string {
+func (x *RotateKeyRequest) GetId() string {
 	if x != nil {
-		return x.Role
+		return x.Id
 	}
 	return ""
 }
 
-func (x *HistoryItem) GetText() string {
+func (x *RotateKeyRequest) GetTenantId() string {
 	if x != nil {
-		return x.Text
+		return x.TenantId
 	}
 	return ""
 }
 
-type ListRequest struct {
+type RevokeKeyRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TenantId      string                 `protobuf:"bytes,2,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListRequest) Reset() {
-	*x = ListRequest{}
-	mi := &file_chat_proto_msgTypes[9]
+func (x *RevokeKeyRequest) Reset() {
+	*x = RevokeKeyRequest{}
+	mi := &file_chat_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListRequest) String() string {
+func (x *RevokeKeyRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListRequest) ProtoMessage() {}
+func (*RevokeKeyRequest) ProtoMessage() {}
 
-func (x *ListRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[9]
+func (x *RevokeKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -535,47 +3767,47 @@ func (x *ListRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
-func (*ListRequest) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use RevokeKeyRequest.ProtoReflect.Descriptor instead.
+func (*RevokeKeyRequest) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{53}
 }
 
-func (x *ListRequest) GetUserId() string {
+func (x *RevokeKeyRequest) GetId() string {
 	if x != nil {
-		return x.UserId
+		return x.Id
 	}
 	return ""
 }
 
-func (x *ListRequest) GetLimit() int32 {
+func (x *RevokeKeyRequest) GetTenantId() string {
 	if x != nil {
-		return x.Limit
+		return x.TenantId
 	}
-	return 0
+	return ""
 }
 
-type ListReply struct {
+type RevokeKeyReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Items         []*HistoryItem         `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListReply) Reset() {
-	*x = ListReply{}
-	mi := &file_chat_proto_msgTypes[10]
+func (x *RevokeKeyReply) Reset() {
+	*x = RevokeKeyReply{}
+	mi := &file_chat_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListReply) String() string {
+func (x *RevokeKeyReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListReply) ProtoMessage() {}
+func (*RevokeKeyReply) ProtoMessage() {}
 
-func (x *ListReply) ProtoReflect() protoreflect.Message {
-	mi := &file_chat_proto_msgTypes[10]
+func (x *RevokeKeyReply) ProtoReflect() protoreflect.Message {
+	mi := &file_chat_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -586,16 +3818,16 @@ func (x *ListReply) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListReply.ProtoReflect.Descriptor instead.
-func (*ListReply) Descriptor() ([]byte, []int) {
-	return file_chat_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use RevokeKeyReply.ProtoReflect.Descriptor instead.
+func (*RevokeKeyReply) Descriptor() ([]byte, []int) {
+	return file_chat_proto_rawDescGZIP(), []int{54}
 }
 
-func (x *ListReply) GetItems() []*HistoryItem {
+func (x *RevokeKeyReply) GetOk() bool {
 	if x != nil {
-		return x.Items
+		return x.Ok
 	}
-	return nil
+	return false
 }
 
 var File_chat_proto protoreflect.FileDescriptor
@@ -603,51 +3835,344 @@ var File_chat_proto protoreflect.FileDescriptor
 const file_chat_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"chat.proto\x12\x04chat\":\n" +
+	"chat.proto\x12\x04chat\"5\n" +
+	"\vChatMessage\x12\x12\n" +
+	"\x04role\x18\x01 \x01(\tR\x04role\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\"}\n" +
 	"\vChatRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
-	"\x04text\x18\x02 \x01(\tR\x04text\"\x99\x01\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12+\n" +
+	"\ahistory\x18\x03 \x03(\v2\x11.chat.ChatMessageR\ahistory\x12\x14\n" +
+	"\x05model\x18\x04 \x01(\tR\x05model\"\xaf\x01\n" +
 	"\fChatResponse\x12\x14\n" +
 	"\x05reply\x18\x01 \x01(\tR\x05reply\x12#\n" +
 	"\rprompt_tokens\x18\x02 \x01(\x05R\fpromptTokens\x12+\n" +
 	"\x11completion_tokens\x18\x03 \x01(\x05R\x10completionTokens\x12!\n" +
-	"\ftotal_tokens\x18\x04 \x01(\x05R\vtotalTokens\"#\n" +
+	"\ftotal_tokens\x18\x04 \x01(\x05R\vtotalTokens\x12\x14\n" +
+	"\x05model\x18\x05 \x01(\tR\x05model\"\xc0\x01\n" +
+	"\tChatChunk\x12\x14\n" +
+	"\x05delta\x18\x01 \x01(\tR\x05delta\x12\x12\n" +
+	"\x04done\x18\x02 \x01(\bR\x04done\x12#\n" +
+	"\rprompt_tokens\x18\x03 \x01(\x05R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\x04 \x01(\x05R\x10completionTokens\x12!\n" +
+	"\ftotal_tokens\x18\x05 \x01(\x05R\vtotalTokens\x12\x14\n" +
+	"\x05model\x18\x06 \x01(\tR\x05model\"\xaa\x02\n" +
 	"\rFilterRequest\x12\x12\n" +
-	"\x04text\x18\x01 \x01(\tR\x04text\"A\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x123\n" +
+	"\tdirection\x18\x03 \x01(\x0e2\x15.chat.FilterDirectionR\tdirection\x12\x18\n" +
+	"\apartial\x18\x04 \x01(\bR\apartial\x12D\n" +
+	"\vpii_offsets\x18\x05 \x03(\v2#.chat.FilterRequest.PiiOffsetsEntryR\n" +
+	"piiOffsets\x12\x18\n" +
+	"\ahistory\x18\x06 \x01(\bR\ahistory\x1a=\n" +
+	"\x0fPiiOffsetsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\x86\x01\n" +
+	"\vFilterMatch\x12\x17\n" +
+	"\arule_id\x18\x01 \x01(\tR\x06ruleId\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\x12\x1a\n" +
+	"\bseverity\x18\x03 \x01(\tR\bseverity\x12\x14\n" +
+	"\x05start\x18\x04 \x01(\x05R\x05start\x12\x10\n" +
+	"\x03end\x18\x05 \x01(\x05R\x03end\"\x7f\n" +
+	"\tPiiEntity\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12 \n" +
+	"\vplaceholder\x18\x02 \x01(\tR\vplaceholder\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\x12\x14\n" +
+	"\x05start\x18\x04 \x01(\x05R\x05start\x12\x10\n" +
+	"\x03end\x18\x05 \x01(\x05R\x03end\"i\n" +
+	"\x0fInjectionSignal\x12\x16\n" +
+	"\x06signal\x18\x01 \x01(\tR\x06signal\x12\x16\n" +
+	"\x06weight\x18\x02 \x01(\x02R\x06weight\x12\x14\n" +
+	"\x05start\x18\x03 \x01(\x05R\x05start\x12\x10\n" +
+	"\x03end\x18\x04 \x01(\x05R\x03end\"\x90\x03\n" +
 	"\vFilterReply\x12\x18\n" +
 	"\aallowed\x18\x01 \x01(\bR\aallowed\x12\x18\n" +
-	"\acleaned\x18\x02 \x01(\tR\acleaned\"?\n" +
+	"\acleaned\x18\x02 \x01(\tR\acleaned\x12'\n" +
+	"\x0fruleset_version\x18\x03 \x01(\tR\x0erulesetVersion\x12+\n" +
+	"\amatches\x18\x04 \x03(\v2\x11.chat.FilterMatchR\amatches\x12\x1a\n" +
+	"\bcategory\x18\x05 \x01(\tR\bcategory\x12\x1a\n" +
+	"\bseverity\x18\x06 \x01(\tR\bseverity\x12!\n" +
+	"\x03pii\x18\a \x03(\v2\x0f.chat.PiiEntityR\x03pii\x12'\n" +
+	"\x0finjection_score\x18\b \x01(\x02R\x0einjectionScore\x12/\n" +
+	"\x13injection_suspected\x18\t \x01(\bR\x12injectionSuspected\x12B\n" +
+	"\x11injection_signals\x18\n" +
+	" \x03(\v2\x15.chat.InjectionSignalR\x10injectionSignals\"\xcc\x02\n" +
+	"\fBlockedEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x02 \x01(\x03R\tcreatedAt\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x04 \x01(\tR\trequestId\x12'\n" +
+	"\x0fruleset_version\x18\x05 \x01(\tR\x0erulesetVersion\x12\x1a\n" +
+	"\bcategory\x18\x06 \x01(\tR\bcategory\x12\x1a\n" +
+	"\bseverity\x18\a \x01(\tR\bseverity\x12\x12\n" +
+	"\x04text\x18\b \x01(\tR\x04text\x12+\n" +
+	"\amatches\x18\t \x03(\v2\x11.chat.FilterMatchR\amatches\x123\n" +
+	"\tdirection\x18\n" +
+	" \x01(\x0e2\x15.chat.FilterDirectionR\tdirection\"_\n" +
+	"\x12ListBlockedRequest\x12\x1b\n" +
+	"\ttenant_id\x18\x01 \x01(\tR\btenantId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x03 \x01(\tR\x06cursor\"_\n" +
+	"\x10ListBlockedReply\x12*\n" +
+	"\x06events\x18\x01 \x03(\v2\x12.chat.BlockedEventR\x06events\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"$\n" +
+	"\x12RecordBlockedReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\"\xc6\x01\n" +
+	"\rDryRunRequest\x12\x16\n" +
+	"\x06policy\x18\x01 \x01(\tR\x06policy\x12\x14\n" +
+	"\x05texts\x18\x02 \x03(\tR\x05texts\x123\n" +
+	"\tdirection\x18\x03 \x01(\x0e2\x15.chat.FilterDirectionR\tdirection\x12%\n" +
+	"\x0epromote_shadow\x18\x04 \x01(\bR\rpromoteShadow\x12+\n" +
+	"\x11candidate_version\x18\x05 \x01(\tR\x10candidateVersion\"\xb9\x02\n" +
+	"\fDryRunResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12'\n" +
+	"\x0fcurrent_blocked\x18\x02 \x01(\bR\x0ecurrentBlocked\x12+\n" +
+	"\x11candidate_blocked\x18\x03 \x01(\bR\x10candidateBlocked\x12\x1a\n" +
+	"\bcategory\x18\x04 \x01(\tR\bcategory\x12\x1a\n" +
+	"\bseverity\x18\x05 \x01(\tR\bseverity\x12+\n" +
+	"\amatches\x18\x06 \x03(\v2\x11.chat.FilterMatchR\amatches\x12'\n" +
+	"\x0finjection_score\x18\a \x01(\x02R\x0einjectionScore\x12/\n" +
+	"\x13injection_suspected\x18\b \x01(\bR\x12injectionSuspected\"\xee\x03\n" +
+	"\vDryRunReply\x12'\n" +
+	"\x0fcurrent_version\x18\x01 \x01(\tR\x0ecurrentVersion\x12+\n" +
+	"\x11candidate_version\x18\x02 \x01(\tR\x10candidateVersion\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total\x12'\n" +
+	"\x0fcurrent_blocked\x18\x04 \x01(\x05R\x0ecurrentBlocked\x12+\n" +
+	"\x11candidate_blocked\x18\x05 \x01(\x05R\x10candidateBlocked\x12#\n" +
+	"\rnewly_blocked\x18\x06 \x01(\x05R\fnewlyBlocked\x12#\n" +
+	"\rnewly_allowed\x18\a \x01(\x05R\fnewlyAllowed\x12]\n" +
+	"\x14candidate_categories\x18\b \x03(\v2*.chat.DryRunReply.CandidateCategoriesEntryR\x13candidateCategories\x12,\n" +
+	"\aresults\x18\t \x03(\v2\x12.chat.DryRunResultR\aresults\x1aF\n" +
+	"\x18CandidateCategoriesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\xed\x01\n" +
+	"\n" +
+	"FilterRule\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\x12\x1a\n" +
+	"\bseverity\x18\x03 \x01(\tR\bseverity\x12\x14\n" +
+	"\x05words\x18\x04 \x03(\tR\x05words\x12\x14\n" +
+	"\x05match\x18\x05 \x01(\tR\x05match\x12\x14\n" +
+	"\x05regex\x18\x06 \x01(\tR\x05regex\x12\x1c\n" +
+	"\tdirection\x18\a \x01(\tR\tdirection\x12#\n" +
+	"\routput_action\x18\b \x01(\tR\foutputAction\x12\x12\n" +
+	"\x04mode\x18\t \x01(\tR\x04mode\"\xcf\x01\n" +
+	"\fFilterPolicy\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x16\n" +
+	"\x06parent\x18\x02 \x01(\tR\x06parent\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x03 \x01(\tR\tcreatedBy\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\x03R\tcreatedAt\x12\x18\n" +
+	"\acomment\x18\x05 \x01(\tR\acomment\x12\x16\n" +
+	"\x06active\x18\x06 \x01(\bR\x06active\x12\x1d\n" +
+	"\n" +
+	"rule_count\x18\a \x01(\x05R\truleCount\"+\n" +
+	"\x13ListPoliciesRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\"s\n" +
+	"\x11ListPoliciesReply\x12.\n" +
+	"\bpolicies\x18\x01 \x03(\v2\x12.chat.FilterPolicyR\bpolicies\x12\x16\n" +
+	"\x06active\x18\x02 \x01(\tR\x06active\x12\x16\n" +
+	"\x06latest\x18\x03 \x01(\tR\x06latest\",\n" +
+	"\x10ListRulesRequest\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\"R\n" +
+	"\x0eListRulesReply\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12&\n" +
+	"\x05rules\x18\x02 \x03(\v2\x10.chat.FilterRuleR\x05rules\"\xa6\x01\n" +
+	"\x0ePutRuleRequest\x12$\n" +
+	"\x04rule\x18\x01 \x01(\v2\x10.chat.FilterRuleR\x04rule\x12\x14\n" +
+	"\x05actor\x18\x02 \x01(\tR\x05actor\x12\x1b\n" +
+	"\tactor_key\x18\x03 \x01(\tR\bactorKey\x12\x18\n" +
+	"\acomment\x18\x04 \x01(\tR\acomment\x12!\n" +
+	"\fbase_version\x18\x05 \x01(\tR\vbaseVersion\"\x93\x01\n" +
+	"\x11DeleteRuleRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05actor\x18\x02 \x01(\tR\x05actor\x12\x1b\n" +
+	"\tactor_key\x18\x03 \x01(\tR\bactorKey\x12\x18\n" +
+	"\acomment\x18\x04 \x01(\tR\acomment\x12!\n" +
+	"\fbase_version\x18\x05 \x01(\tR\vbaseVersion\"~\n" +
+	"\x15ActivatePolicyRequest\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x14\n" +
+	"\x05actor\x18\x02 \x01(\tR\x05actor\x12\x1b\n" +
+	"\tactor_key\x18\x03 \x01(\tR\bactorKey\x12\x18\n" +
+	"\acomment\x18\x04 \x01(\tR\acomment\"\x86\x02\n" +
+	"\x10FilterAuditEntry\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x02 \x01(\x03R\tcreatedAt\x12\x14\n" +
+	"\x05actor\x18\x03 \x01(\tR\x05actor\x12\x1b\n" +
+	"\tactor_key\x18\x04 \x01(\tR\bactorKey\x12\x16\n" +
+	"\x06action\x18\x05 \x01(\tR\x06action\x12\x16\n" +
+	"\x06target\x18\x06 \x01(\tR\x06target\x12\x18\n" +
+	"\aversion\x18\a \x01(\tR\aversion\x12\x16\n" +
+	"\x06before\x18\b \x01(\tR\x06before\x12\x14\n" +
+	"\x05after\x18\t \x01(\tR\x05after\x12\x18\n" +
+	"\acomment\x18\n" +
+	" \x01(\tR\acomment\"X\n" +
+	"\x10ListAuditRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\x12\x16\n" +
+	"\x06target\x18\x03 \x01(\tR\x06target\"c\n" +
+	"\x0eListAuditReply\x120\n" +
+	"\aentries\x18\x01 \x03(\v2\x16.chat.FilterAuditEntryR\aentries\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"?\n" +
 	"\fTokenRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
-	"\x06tokens\x18\x02 \x01(\x05R\x06tokens\"D\n" +
+	"\x06tokens\x18\x02 \x01(\x05R\x06tokens\"w\n" +
 	"\n" +
 	"TokenReply\x12\x18\n" +
 	"\aallowed\x18\x01 \x01(\bR\aallowed\x12\x1c\n" +
-	"\tremaining\x18\x02 \x01(\x03R\tremaining\"N\n" +
+	"\tremaining\x18\x02 \x01(\x03R\tremaining\x12\x16\n" +
+	"\x06window\x18\x03 \x01(\tR\x06window\x12\x19\n" +
+	"\breset_at\x18\x04 \x01(\x03R\aresetAt\"b\n" +
+	"\x0eReserveRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06tokens\x18\x02 \x01(\x05R\x06tokens\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x05R\n" +
+	"ttlSeconds\"\xa0\x01\n" +
+	"\fReserveReply\x12\x18\n" +
+	"\aallowed\x18\x01 \x01(\bR\aallowed\x12\x1c\n" +
+	"\tremaining\x18\x02 \x01(\x03R\tremaining\x12%\n" +
+	"\x0ereservation_id\x18\x03 \x01(\tR\rreservationId\x12\x16\n" +
+	"\x06window\x18\x04 \x01(\tR\x06window\x12\x19\n" +
+	"\breset_at\x18\x05 \x01(\x03R\aresetAt\"g\n" +
+	"\rCommitRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12%\n" +
+	"\x0ereservation_id\x18\x02 \x01(\tR\rreservationId\x12\x16\n" +
+	"\x06tokens\x18\x03 \x01(\x05R\x06tokens\"P\n" +
+	"\x0eReleaseRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12%\n" +
+	"\x0ereservation_id\x18\x02 \x01(\tR\rreservationId\"w\n" +
 	"\vSaveRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
 	"\x04role\x18\x02 \x01(\tR\x04role\x12\x12\n" +
-	"\x04text\x18\x03 \x01(\tR\x04text\"\x1b\n" +
+	"\x04text\x18\x03 \x01(\tR\x04text\x12'\n" +
+	"\x0fconversation_id\x18\x04 \x01(\tR\x0econversationId\"\x1b\n" +
 	"\tSaveReply\x12\x0e\n" +
-	"\x02ok\x18\x01 \x01(\bR\x02ok\"5\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\"d\n" +
 	"\vHistoryItem\x12\x12\n" +
 	"\x04role\x18\x01 \x01(\tR\x04role\x12\x12\n" +
-	"\x04text\x18\x02 \x01(\tR\x04text\"<\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\x03R\tcreatedAt\"\xb0\x01\n" +
 	"\vListRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
-	"\x05limit\x18\x02 \x01(\x05R\x05limit\"4\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12'\n" +
+	"\x0fconversation_id\x18\x03 \x01(\tR\x0econversationId\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursor\x121\n" +
+	"\tdirection\x18\x05 \x01(\x0e2\x13.chat.ListDirectionR\tdirection\"U\n" +
 	"\tListReply\x12'\n" +
-	"\x05items\x18\x01 \x03(\v2\x11.chat.HistoryItemR\x05items2?\n" +
+	"\x05items\x18\x01 \x03(\v2\x11.chat.HistoryItemR\x05items\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"r\n" +
+	"\fConversation\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x04 \x01(\x03R\tupdatedAt\"J\n" +
+	"\x19CreateConversationRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\"I\n" +
+	"\x18ListConversationsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"R\n" +
+	"\x16ListConversationsReply\x128\n" +
+	"\rconversations\x18\x01 \x03(\v2\x12.chat.ConversationR\rconversations\"s\n" +
+	"\x19RenameConversationRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
+	"\x0fconversation_id\x18\x02 \x01(\tR\x0econversationId\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\"]\n" +
+	"\x19DeleteConversationRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
+	"\x0fconversation_id\x18\x02 \x01(\tR\x0econversationId\")\n" +
+	"\x17DeleteConversationReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\"\xd0\x01\n" +
+	"\x06ApiKey\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06prefix\x18\x02 \x01(\tR\x06prefix\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x1b\n" +
+	"\ttenant_id\x18\x04 \x01(\tR\btenantId\x12\x16\n" +
+	"\x06scopes\x18\x05 \x03(\tR\x06scopes\x12\x12\n" +
+	"\x04name\x18\x06 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\a \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"revoked_at\x18\b \x01(\x03R\trevokedAt\"'\n" +
+	"\x13AuthenticateRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"s\n" +
+	"\x0fIssueKeyRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\ttenant_id\x18\x02 \x01(\tR\btenantId\x12\x16\n" +
+	"\x06scopes\x18\x03 \x03(\tR\x06scopes\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\"G\n" +
+	"\rIssueKeyReply\x12\x1e\n" +
+	"\x03key\x18\x01 \x01(\v2\f.chat.ApiKeyR\x03key\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret\"G\n" +
+	"\x0fListKeysRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\ttenant_id\x18\x02 \x01(\tR\btenantId\"1\n" +
+	"\rListKeysReply\x12 \n" +
+	"\x04keys\x18\x01 \x03(\v2\f.chat.ApiKeyR\x04keys\"?\n" +
+	"\x10RotateKeyRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\ttenant_id\x18\x02 \x01(\tR\btenantId\"?\n" +
+	"\x10RevokeKeyRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\ttenant_id\x18\x02 \x01(\tR\btenantId\" \n" +
+	"\x0eRevokeKeyReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok*(\n" +
+	"\x0fFilterDirection\x12\t\n" +
+	"\x05INPUT\x10\x00\x12\n" +
+	"\n" +
+	"\x06OUTPUT\x10\x01*%\n" +
+	"\rListDirection\x12\t\n" +
+	"\x05OLDER\x10\x00\x12\t\n" +
+	"\x05NEWER\x10\x012w\n" +
 	"\n" +
 	"LLMService\x121\n" +
-	"\bGenerate\x12\x11.chat.ChatRequest\x1a\x12.chat.ChatResponse2A\n" +
+	"\bGenerate\x12\x11.chat.ChatRequest\x1a\x12.chat.ChatResponse\x126\n" +
+	"\x0eGenerateStream\x12\x11.chat.ChatRequest\x1a\x0f.chat.ChatChunk0\x012\xf3\x01\n" +
 	"\rFilterService\x120\n" +
-	"\x06Filter\x12\x13.chat.FilterRequest\x1a\x11.chat.FilterReply2C\n" +
+	"\x06Filter\x12\x13.chat.FilterRequest\x1a\x11.chat.FilterReply\x12?\n" +
+	"\vListBlocked\x12\x18.chat.ListBlockedRequest\x1a\x16.chat.ListBlockedReply\x12=\n" +
+	"\rRecordBlocked\x12\x12.chat.BlockedEvent\x1a\x18.chat.RecordBlockedReply\x120\n" +
+	"\x06DryRun\x12\x13.chat.DryRunRequest\x1a\x11.chat.DryRunReply2\xbc\x03\n" +
+	"\x12FilterAdminService\x12B\n" +
+	"\fListPolicies\x12\x19.chat.ListPoliciesRequest\x1a\x17.chat.ListPoliciesReply\x129\n" +
+	"\tListRules\x12\x16.chat.ListRulesRequest\x1a\x14.chat.ListRulesReply\x126\n" +
+	"\n" +
+	"CreateRule\x12\x14.chat.PutRuleRequest\x1a\x12.chat.FilterPolicy\x126\n" +
+	"\n" +
+	"UpdateRule\x12\x14.chat.PutRuleRequest\x1a\x12.chat.FilterPolicy\x129\n" +
+	"\n" +
+	"DeleteRule\x12\x17.chat.DeleteRuleRequest\x1a\x12.chat.FilterPolicy\x12A\n" +
+	"\x0eActivatePolicy\x12\x1b.chat.ActivatePolicyRequest\x1a\x12.chat.FilterPolicy\x129\n" +
+	"\tListAudit\x12\x16.chat.ListAuditRequest\x1a\x14.chat.ListAuditReply2\xdc\x01\n" +
 	"\fTokenService\x123\n" +
-	"\vCheckAndInc\x12\x12.chat.TokenRequest\x1a\x10.chat.TokenReply2h\n" +
+	"\vCheckAndInc\x12\x12.chat.TokenRequest\x1a\x10.chat.TokenReply\x123\n" +
+	"\aReserve\x12\x14.chat.ReserveRequest\x1a\x12.chat.ReserveReply\x12/\n" +
+	"\x06Commit\x12\x13.chat.CommitRequest\x1a\x10.chat.TokenReply\x121\n" +
+	"\aRelease\x12\x14.chat.ReleaseRequest\x1a\x10.chat.TokenReply2\xa7\x03\n" +
 	"\x0eHistoryService\x12*\n" +
 	"\x04Save\x12\x11.chat.SaveRequest\x1a\x0f.chat.SaveReply\x12*\n" +
-	"\x04List\x12\x11.chat.ListRequest\x1a\x0f.chat.ListReplyB\n" +
+	"\x04List\x12\x11.chat.ListRequest\x1a\x0f.chat.ListReply\x12I\n" +
+	"\x12CreateConversation\x12\x1f.chat.CreateConversationRequest\x1a\x12.chat.Conversation\x12Q\n" +
+	"\x11ListConversations\x12\x1e.chat.ListConversationsRequest\x1a\x1c.chat.ListConversationsReply\x12I\n" +
+	"\x12RenameConversation\x12\x1f.chat.RenameConversationRequest\x1a\x12.chat.Conversation\x12T\n" +
+	"\x12DeleteConversation\x12\x1f.chat.DeleteConversationRequest\x1a\x1d.chat.DeleteConversationReply2\xab\x02\n" +
+	"\vAuthService\x127\n" +
+	"\fAuthenticate\x12\x19.chat.AuthenticateRequest\x1a\f.chat.ApiKey\x126\n" +
+	"\bIssueKey\x12\x15.chat.IssueKeyRequest\x1a\x13.chat.IssueKeyReply\x126\n" +
+	"\bListKeys\x12\x15.chat.ListKeysRequest\x1a\x13.chat.ListKeysReply\x128\n" +
+	"\tRotateKey\x12\x16.chat.RotateKeyRequest\x1a\x13.chat.IssueKeyReply\x129\n" +
+	"\tRevokeKey\x12\x16.chat.RevokeKeyRequest\x1a\x14.chat.RevokeKeyReplyB\n" +
 	"Z\b./chatpbb\x06proto3"
 
 var (
@@ -662,37 +4187,153 @@ func file_chat_proto_rawDescGZIP() []byte {
 	return file_chat_proto_rawDescData
 }
 
-var file_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_chat_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_chat_proto_msgTypes = make([]protoimpl.MessageInfo, 57)
 var file_chat_proto_goTypes = []any{
-	(*ChatRequest)(nil),   // 0: chat.ChatRequest
-	(*ChatResponse)(nil),  // 1: chat.ChatResponse
-	(*FilterRequest)(nil), // 2: chat.FilterRequest
-	(*FilterReply)(nil),   // 3: chat.FilterReply
-	(*TokenRequest)(nil),  // 4: chat.TokenRequest
-	(*TokenReply)(nil),    // 5: chat.TokenReply
-	(*SaveRequest)(nil),   // 6: chat.SaveRequest
-	(*SaveReply)(nil),     // 7: chat.SaveReply
-	(*HistoryItem)(nil),   // 8: chat.HistoryItem
-	(*ListRequest)(nil),   // 9: chat.ListRequest
-	(*ListReply)(nil),     // 10: chat.ListReply
+	(FilterDirection)(0),              // 0: chat.FilterDirection
+	(ListDirection)(0),                // 1: chat.ListDirection
+	(*ChatMessage)(nil),               // 2: chat.ChatMessage
+	(*ChatRequest)(nil),               // 3: chat.ChatRequest
+	(*ChatResponse)(nil),              // 4: chat.ChatResponse
+	(*ChatChunk)(nil),                 // 5: chat.ChatChunk
+	(*FilterRequest)(nil),             // 6: chat.FilterRequest
+	(*FilterMatch)(nil),               // 7: chat.FilterMatch
+	(*PiiEntity)(nil),                 // 8: chat.PiiEntity
+	(*InjectionSignal)(nil),           // 9: chat.InjectionSignal
+	(*FilterReply)(nil),               // 10: chat.FilterReply
+	(*BlockedEvent)(nil),              // 11: chat.BlockedEvent
+	(*ListBlockedRequest)(nil),        // 12: chat.ListBlockedRequest
+	(*ListBlockedReply)(nil),          // 13: chat.ListBlockedReply
+	(*RecordBlockedReply)(nil),        // 14: chat.RecordBlockedReply
+	(*DryRunRequest)(nil),             // 15: chat.DryRunRequest
+	(*DryRunResult)(nil),              // 16: chat.DryRunResult
+	(*DryRunReply)(nil),               // 17: chat.DryRunReply
+	(*FilterRule)(nil),                // 18: chat.FilterRule
+	(*FilterPolicy)(nil),              // 19: chat.FilterPolicy
+	(*ListPoliciesRequest)(nil),       // 20: chat.ListPoliciesRequest
+	(*ListPoliciesReply)(nil),         // 21: chat.ListPoliciesReply
+	(*ListRulesRequest)(nil),          // 22: chat.ListRulesRequest
+	(*ListRulesReply)(nil),            // 23: chat.ListRulesReply
+	(*PutRuleRequest)(nil),            // 24: chat.PutRuleRequest
+	(*DeleteRuleRequest)(nil),         // 25: chat.DeleteRuleRequest
+	(*ActivatePolicyRequest)(nil),     // 26: chat.ActivatePolicyRequest
+	(*FilterAuditEntry)(nil),          // 27: chat.FilterAuditEntry
+	(*ListAuditRequest)(nil),          // 28: chat.ListAuditRequest
+	(*ListAuditReply)(nil),            // 29: chat.ListAuditReply
+	(*TokenRequest)(nil),              // 30: chat.TokenRequest
+	(*TokenReply)(nil),                // 31: chat.TokenReply
+	(*ReserveRequest)(nil),            // 32: chat.ReserveRequest
+	(*ReserveReply)(nil),              // 33: chat.ReserveReply
+	(*CommitRequest)(nil),             // 34: chat.CommitRequest
+	(*ReleaseRequest)(nil),            // 35: chat.ReleaseRequest
+	(*SaveRequest)(nil),               // 36: chat.SaveRequest
+	(*SaveReply)(nil),                 // 37: chat.SaveReply
+	(*HistoryItem)(nil),               // 38: chat.HistoryItem
+	(*ListRequest)(nil),               // 39: chat.ListRequest
+	(*ListReply)(nil),                 // 40: chat.ListReply
+	(*Conversation)(nil),              // 41: chat.Conversation
+	(*CreateConversationRequest)(nil), // 42: chat.CreateConversationRequest
+	(*ListConversationsRequest)(nil),  // 43: chat.ListConversationsRequest
+	(*ListConversationsReply)(nil),    // 44: chat.ListConversationsReply
+	(*RenameConversationRequest)(nil), // 45: chat.RenameConversationRequest
+	(*DeleteConversationRequest)(nil), // 46: chat.DeleteConversationRequest
+	(*DeleteConversationReply)(nil),   // 47: chat.DeleteConversationReply
+	(*ApiKey)(nil),                    // 48: chat.ApiKey
+	(*AuthenticateRequest)(nil),       // 49: chat.AuthenticateRequest
+	(*IssueKeyRequest)(nil),           // 50: chat.IssueKeyRequest
+	(*IssueKeyReply)(nil),             // 51: chat.IssueKeyReply
+	(*ListKeysRequest)(nil),           // 52: chat.ListKeysRequest
+	(*ListKeysReply)(nil),             // 53: chat.ListKeysReply
+	(*RotateKeyRequest)(nil),          // 54: chat.RotateKeyRequest
+	(*RevokeKeyRequest)(nil),          // 55: chat.RevokeKeyRequest
+	(*RevokeKeyReply)(nil),            // 56: chat.RevokeKeyReply
+	nil,                               // 57: chat.FilterRequest.PiiOffsetsEntry
+	nil,                               // 58: chat.DryRunReply.CandidateCategoriesEntry
 }
 var file_chat_proto_depIdxs = []int32{
-	8,  // 0: chat.ListReply.items:type_name -> chat.HistoryItem
-	0,  // 1: chat.LLMService.Generate:input_type -> chat.ChatRequest
-	2,  // 2: chat.FilterService.Filter:input_type -> chat.FilterRequest
-	4,  // 3: chat.TokenService.CheckAndInc:input_type -> chat.TokenRequest
-	6,  // 4: chat.HistoryService.Save:input_type -> chat.SaveRequest
-	9,  // 5: chat.HistoryService.List:input_type -> chat.ListRequest
-	1,  // 6: chat.LLMService.Generate:output_type -> chat.ChatResponse
-	3,  // 7: chat.FilterService.Filter:output_type -> chat.FilterReply
-	5,  // 8: chat.TokenService.CheckAndInc:output_type -> chat.TokenReply
-	7,  // 9: chat.HistoryService.Save:output_type -> chat.SaveReply
-	10, // 10: chat.HistoryService.List:output_type -> chat.ListReply
-	6,  // [6:11] is the sub-list for method output_type
-	1,  // [1:6] is the sub-list for method input_type
-	1,  // [1:1] is the sub-list for extension type_name
-	1,  // [1:1] is the sub-list for extension extendee
-	0,  // [0:1] is the sub-list for field type_name
+	2,  // 0: chat.ChatRequest.history:type_name -> chat.ChatMessage
+	0,  // 1: chat.FilterRequest.direction:type_name -> chat.FilterDirection
+	57, // 2: chat.FilterRequest.pii_offsets:type_name -> chat.FilterRequest.PiiOffsetsEntry
+	7,  // 3: chat.FilterReply.matches:type_name -> chat.FilterMatch
+	8,  // 4: chat.FilterReply.pii:type_name -> chat.PiiEntity
+	9,  // 5: chat.FilterReply.injection_signals:type_name -> chat.InjectionSignal
+	7,  // 6: chat.BlockedEvent.matches:type_name -> chat.FilterMatch
+	0,  // 7: chat.BlockedEvent.direction:type_name -> chat.FilterDirection
+	11, // 8: chat.ListBlockedReply.events:type_name -> chat.BlockedEvent
+	0,  // 9: chat.DryRunRequest.direction:type_name -> chat.FilterDirection
+	7,  // 10: chat.DryRunResult.matches:type_name -> chat.FilterMatch
+	58, // 11: chat.DryRunReply.candidate_categories:type_name -> chat.DryRunReply.CandidateCategoriesEntry
+	16, // 12: chat.DryRunReply.results:type_name -> chat.DryRunResult
+	19, // 13: chat.ListPoliciesReply.policies:type_name -> chat.FilterPolicy
+	18, // 14: chat.ListRulesReply.rules:type_name -> chat.FilterRule
+	18, // 15: chat.PutRuleRequest.rule:type_name -> chat.FilterRule
+	27, // 16: chat.ListAuditReply.entries:type_name -> chat.FilterAuditEntry
+	1,  // 17: chat.ListRequest.direction:type_name -> chat.ListDirection
+	38, // 18: chat.ListReply.items:type_name -> chat.HistoryItem
+	41, // 19: chat.ListConversationsReply.conversations:type_name -> chat.Conversation
+	48, // 20: chat.IssueKeyReply.key:type_name -> chat.ApiKey
+	48, // 21: chat.ListKeysReply.keys:type_name -> chat.ApiKey
+	3,  // 22: chat.LLMService.Generate:input_type -> chat.ChatRequest
+	3,  // 23: chat.LLMService.GenerateStream:input_type -> chat.ChatRequest
+	6,  // 24: chat.FilterService.Filter:input_type -> chat.FilterRequest
+	12, // 25: chat.FilterService.ListBlocked:input_type -> chat.ListBlockedRequest
+	11, // 26: chat.FilterService.RecordBlocked:input_type -> chat.BlockedEvent
+	15, // 27: chat.FilterService.DryRun:input_type -> chat.DryRunRequest
+	20, // 28: chat.FilterAdminService.ListPolicies:input_type -> chat.ListPoliciesRequest
+	22, // 29: chat.FilterAdminService.ListRules:input_type -> chat.ListRulesRequest
+	24, // 30: chat.FilterAdminService.CreateRule:input_type -> chat.PutRuleRequest
+	24, // 31: chat.FilterAdminService.UpdateRule:input_type -> chat.PutRuleRequest
+	25, // 32: chat.FilterAdminService.DeleteRule:input_type -> chat.DeleteRuleRequest
+	26, // 33: chat.FilterAdminService.ActivatePolicy:input_type -> chat.ActivatePolicyRequest
+	28, // 34: chat.FilterAdminService.ListAudit:input_type -> chat.ListAuditRequest
+	30, // 35: chat.TokenService.CheckAndInc:input_type -> chat.TokenRequest
+	32, // 36: chat.TokenService.Reserve:input_type -> chat.ReserveRequest
+	34, // 37: chat.TokenService.Commit:input_type -> chat.CommitRequest
+	35, // 38: chat.TokenService.Release:input_type -> chat.ReleaseRequest
+	36, // 39: chat.HistoryService.Save:input_type -> chat.SaveRequest
+	39, // 40: chat.HistoryService.List:input_type -> chat.ListRequest
+	42, // 41: chat.HistoryService.CreateConversation:input_type -> chat.CreateConversationRequest
+	43, // 42: chat.HistoryService.ListConversations:input_type -> chat.ListConversationsRequest
+	45, // 43: chat.HistoryService.RenameConversation:input_type -> chat.RenameConversationRequest
+	46, // 44: chat.HistoryService.DeleteConversation:input_type -> chat.DeleteConversationRequest
+	49, // 45: chat.AuthService.Authenticate:input_type -> chat.AuthenticateRequest
+	50, // 46: chat.AuthService.IssueKey:input_type -> chat.IssueKeyRequest
+	52, // 47: chat.AuthService.ListKeys:input_type -> chat.ListKeysRequest
+	54, // 48: chat.AuthService.RotateKey:input_type -> chat.RotateKeyRequest
+	55, // 49: chat.AuthService.RevokeKey:input_type -> chat.RevokeKeyRequest
+	4,  // 50: chat.LLMService.Generate:output_type -> chat.ChatResponse
+	5,  // 51: chat.LLMService.GenerateStream:output_type -> chat.ChatChunk
+	10, // 52: chat.FilterService.Filter:output_type -> chat.FilterReply
+	13, // 53: chat.FilterService.ListBlocked:output_type -> chat.ListBlockedReply
+	14, // 54: chat.FilterService.RecordBlocked:output_type -> chat.RecordBlockedReply
+	17, // 55: chat.FilterService.DryRun:output_type -> chat.DryRunReply
+	21, // 56: chat.FilterAdminService.ListPolicies:output_type -> chat.ListPoliciesReply
+	23, // 57: chat.FilterAdminService.ListRules:output_type -> chat.ListRulesReply
+	19, // 58: chat.FilterAdminService.CreateRule:output_type -> chat.FilterPolicy
+	19, // 59: chat.FilterAdminService.UpdateRule:output_type -> chat.FilterPolicy
+	19, // 60: chat.FilterAdminService.DeleteRule:output_type -> chat.FilterPolicy
+	19, // 61: chat.FilterAdminService.ActivatePolicy:output_type -> chat.FilterPolicy
+	29, // 62: chat.FilterAdminService.ListAudit:output_type -> chat.ListAuditReply
+	31, // 63: chat.TokenService.CheckAndInc:output_type -> chat.TokenReply
+	33, // 64: chat.TokenService.Reserve:output_type -> chat.ReserveReply
+	31, // 65: chat.TokenService.Commit:output_type -> chat.TokenReply
+	31, // 66: chat.TokenService.Release:output_type -> chat.TokenReply
+	37, // 67: chat.HistoryService.Save:output_type -> chat.SaveReply
+	40, // 68: chat.HistoryService.List:output_type -> chat.ListReply
+	41, // 69: chat.HistoryService.CreateConversation:output_type -> chat.Conversation
+	44, // 70: chat.HistoryService.ListConversations:output_type -> chat.ListConversationsReply
+	41, // 71: chat.HistoryService.RenameConversation:output_type -> chat.Conversation
+	47, // 72: chat.HistoryService.DeleteConversation:output_type -> chat.DeleteConversationReply
+	48, // 73: chat.AuthService.Authenticate:output_type -> chat.ApiKey
+	51, // 74: chat.AuthService.IssueKey:output_type -> chat.IssueKeyReply
+	53, // 75: chat.AuthService.ListKeys:output_type -> chat.ListKeysReply
+	51, // 76: chat.AuthService.RotateKey:output_type -> chat.IssueKeyReply
+	56, // 77: chat.AuthService.RevokeKey:output_type -> chat.RevokeKeyReply
+	50, // [50:78] is the sub-list for method output_type
+	22, // [22:50] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
 }
 
 func init() { file_chat_proto_init() }
@@ -705,13 +4346,14 @@ func file_chat_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_chat_proto_rawDesc), len(file_chat_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   11,
+			NumEnums:      2,
+			NumMessages:   57,
 			NumExtensions: 0,
-			NumServices:   4,
+			NumServices:   6,
 		},
 		GoTypes:           file_chat_proto_goTypes,
 		DependencyIndexes: file_chat_proto_depIdxs,
+		EnumInfos:         file_chat_proto_enumTypes,
 		MessageInfos:      file_chat_proto_msgTypes,
 	}.Build()
 	File_chat_proto = out.File
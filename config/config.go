@@ -0,0 +1,333 @@
+// Package config 是各服务共用的配置：内置默认值 ← 配置文件（YAML/TOML）← 环境 profile 覆盖文件 ← 环境变量，
+// 后者覆盖前者。每个 main 启动时调用 MustLoad，只校验本服务用到的部分。
+//
+// 日志级别（LOG_LEVEL）与链路追踪（OTEL_*）仍只读环境变量，沿用 OpenTelemetry 的标准约定。
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Config 是所有服务的配置；字段上的 env 标签为对应的覆盖环境变量，secret 标签的字段在 -print-config 时打码
+type Config struct {
+	Redis   Redis   `yaml:"redis" toml:"redis"`
+	MySQL   MySQL   `yaml:"mysql" toml:"mysql"`
+	Gateway Gateway `yaml:"gateway" toml:"gateway"`
+	Token   Token   `yaml:"token" toml:"token"`
+	Filter  Filter  `yaml:"filter" toml:"filter"`
+	History History `yaml:"history" toml:"history"`
+	LLM     LLM     `yaml:"llm" toml:"llm"`
+	Auth    Auth    `yaml:"auth" toml:"auth"`
+
+	// 以下不来自配置文件，仅用于 -print-config 展示
+	Profile string   `yaml:"-" toml:"-"`
+	Sources []string `yaml:"-" toml:"-"`
+}
+
+type Redis struct {
+	Addr string `yaml:"addr" toml:"addr" env:"REDIS_ADDR"`
+}
+
+// MySQL 设置了 dsn 时直接使用，否则由其余几项拼出
+type MySQL struct {
+	DSN      string `yaml:"dsn" toml:"dsn" env:"MYSQL_DSN" secret:"dsn"`
+	User     string `yaml:"user" toml:"user" env:"MYSQL_USER"`
+	Password string `yaml:"password" toml:"password" env:"MYSQL_PASSWORD" secret:"true"`
+	Addr     string `yaml:"addr" toml:"addr" env:"MYSQL_ADDR"`
+	Database string `yaml:"database" toml:"database" env:"MYSQL_DATABASE"`
+}
+
+// DataSource 返回 database/sql 用的 DSN
+func (m MySQL) DataSource() string {
+	if m.DSN != "" {
+		return m.DSN
+	}
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true&charset=utf8mb4,utf8", m.User, m.Password, m.Addr, m.Database)
+}
+
+// Server 是 gRPC 服务共有的监听地址；各服务进程只读自己那一节，所以环境变量可以同名
+type Server struct {
+	Addr        string `yaml:"addr" toml:"addr" env:"LISTEN_ADDR"`
+	MetricsAddr string `yaml:"metrics_addr" toml:"metrics_addr" env:"METRICS_ADDR"`
+}
+
+type Gateway struct {
+	Addr      string    `yaml:"addr" toml:"addr" env:"LISTEN_ADDR"`
+	Backends  Backends  `yaml:"backends" toml:"backends"`
+	Timeouts  Timeouts  `yaml:"timeouts" toml:"timeouts"`
+	RateLimit RateLimit `yaml:"rate_limit" toml:"rate_limit"`
+	// 每次对话先预占的 token 数，结算时按真实用量多退少补
+	PreReserve int32      `yaml:"pre_reserve" toml:"pre_reserve" env:"PRE_RESERVE"`
+	Moderation Moderation `yaml:"moderation" toml:"moderation"`
+}
+
+// Moderation 是模型回复（输出方向）的审核：在返回与保存之前交给 filterserver
+type Moderation struct {
+	Output   bool `yaml:"output" toml:"output" env:"OUTPUT_MODERATION"`
+	FailOpen bool `yaml:"fail_open" toml:"fail_open" env:"OUTPUT_MODERATION_FAIL_OPEN"` // filterserver 出错时放行回复（默认不放行）
+	// 流式：每积累 StreamChunk 个字审核一次新增部分（连同之前末尾 2×StreamHold 个字）；已审核部分末尾 StreamHold 个字暂不推送，
+	// 让跨两次审核的命中能被整体识别，不会先漏出前半截。流结束时再审核一次全文
+	StreamChunk int `yaml:"stream_chunk" toml:"stream_chunk"`
+	StreamHold  int `yaml:"stream_hold" toml:"stream_hold"`
+
+	Injection Injection `yaml:"injection" toml:"injection"`
+}
+
+// Injection 是用户输入被 filterserver 判为疑似提示词注入（injection_suspected）时的处理：
+// block 拦截 | warn 放行并在响应里附带警告 | tag 只记日志、指标与 trace | off 忽略
+type Injection struct {
+	Action  string `yaml:"action" toml:"action" env:"INJECTION_ACTION"`
+	Tenants string `yaml:"tenants" toml:"tenants" env:"INJECTION_TENANT_ACTIONS"` // 按租户覆盖，如 "acme:block,beta:tag"
+}
+
+// Backends 是网关拨号的各 gRPC 服务地址
+type Backends struct {
+	Token   string `yaml:"token" toml:"token" env:"TOKEN_SERVICE_ADDR"`
+	Filter  string `yaml:"filter" toml:"filter" env:"FILTER_SERVICE_ADDR"`
+	History string `yaml:"history" toml:"history" env:"HISTORY_SERVICE_ADDR"`
+	LLM     string `yaml:"llm" toml:"llm" env:"LLM_SERVICE_ADDR"`
+	Auth    string `yaml:"auth" toml:"auth" env:"AUTH_SERVICE_ADDR"`
+}
+
+// Timeouts 是网关调用后端的分段超时
+type Timeouts struct {
+	RPC    Duration `yaml:"rpc" toml:"rpc" env:"RPC_TIMEOUT"`          // 鉴权 / 过滤 / 配额 / 保存历史
+	Query  Duration `yaml:"query" toml:"query" env:"QUERY_TIMEOUT"`    // 历史、会话与 key 管理接口
+	LLM    Duration `yaml:"llm" toml:"llm" env:"LLM_TIMEOUT"`          // /chat 的一次生成
+	Stream Duration `yaml:"stream" toml:"stream" env:"STREAM_TIMEOUT"` // /chat/stream 的整个流
+}
+
+type RateLimit struct {
+	Limits  string   `yaml:"limits" toml:"limits" env:"RATE_LIMITS"` // 如 "user:3/1m,key:10/1m,global:60/1m"
+	Mode    string   `yaml:"mode" toml:"mode" env:"RATE_LIMIT_MODE"` // reject | queue
+	Queue   int      `yaml:"queue" toml:"queue" env:"RATE_LIMIT_QUEUE"`
+	MaxWait Duration `yaml:"max_wait" toml:"max_wait" env:"RATE_LIMIT_MAX_WAIT"`
+}
+
+type Token struct {
+	Server     `yaml:",inline"`
+	DailyLimit int64 `yaml:"daily_limit" toml:"daily_limit" env:"DAILY_LIMIT"`
+	// 配额窗口，如 "tokens:2000/minute,tokens:5000/day,tokens:100000/month,requests:200/day"（单位:上限/周期，
+	// 周期为自然分钟/小时/日/月）；为空时只有 tokens:<daily_limit>/day
+	Quotas       string   `yaml:"quotas" toml:"quotas" env:"TOKEN_QUOTAS"`
+	HoldTTL      Duration `yaml:"hold_ttl" toml:"hold_ttl" env:"RESERVATION_TTL"` // 预占默认有效期
+	ReapInterval Duration `yaml:"reap_interval" toml:"reap_interval"`             // 过期预占回收周期
+}
+
+type Filter struct {
+	Server    `yaml:",inline"`
+	RulesFile string `yaml:"rules_file" toml:"rules_file" env:"FILTER_RULES"` // 规则文件，改动后自动热更新（也可 SIGHUP）
+	// 拦截事件写入 Redis Stream filter:blocked 供人工复核，只保留最近 EventsMaxLen 条（近似裁剪）
+	EventsMaxLen int64 `yaml:"events_max_len" toml:"events_max_len" env:"FILTER_EVENTS_MAX_LEN"`
+	// 规则来源：file（rules_file，热更新）| mysql（管理接口维护的版本，激活后经 Redis pub/sub 通知各副本；
+	// 还没有激活任何版本时仍用 rules_file）。mysql 模式下每隔 SyncInterval 再核对一次，补上错过的通知
+	Store        string   `yaml:"store" toml:"store" env:"FILTER_RULES_STORE"`
+	SyncInterval Duration `yaml:"sync_interval" toml:"sync_interval"`
+}
+
+type History struct {
+	Server `yaml:",inline"`
+	CacheN int `yaml:"cache_n" toml:"cache_n" env:"HISTORY_CACHE_N"` // 每个会话在 Redis 里缓存的最近消息数
+}
+
+type LLM struct {
+	Server        `yaml:",inline"`
+	Provider      string `yaml:"provider" toml:"provider" env:"LLM_PROVIDER"` // openai | mock
+	Model         string `yaml:"model" toml:"model" env:"OPENAI_MODEL"`       // 未配置 models 时的唯一模型
+	Models        string `yaml:"models" toml:"models" env:"LLM_MODELS"`       // 白名单 + 回退链，如 "gpt-4o-mini:10s,gpt-4.1-nano:8s"
+	ContextBudget int    `yaml:"context_budget" toml:"context_budget" env:"CONTEXT_TOKEN_BUDGET"`
+	OpenAI        OpenAI `yaml:"openai" toml:"openai"`
+	Mock          Mock   `yaml:"mock" toml:"mock"`
+}
+
+type OpenAI struct {
+	APIKey string `yaml:"api_key" toml:"api_key" env:"OPENAI_API_KEY" secret:"true"`
+}
+
+// Mock 见 llmserver/mock.go
+type Mock struct {
+	Reply   string   `yaml:"reply" toml:"reply" env:"MOCK_REPLY"`
+	Latency Duration `yaml:"latency" toml:"latency" env:"MOCK_LATENCY"`
+	Error   string   `yaml:"error" toml:"error" env:"MOCK_ERROR"`
+}
+
+type Auth struct {
+	Server   `yaml:",inline"`
+	CacheTTL Duration `yaml:"cache_ttl" toml:"cache_ttl" env:"AUTH_CACHE_TTL"` // 校验结果在 Redis 的缓存时长
+}
+
+// Default 返回内置默认值，即原先写死在各服务里的取值
+func Default() *Config {
+	return &Config{
+		Redis: Redis{Addr: "localhost:6379"},
+		MySQL: MySQL{User: "root", Password: "root", Addr: "localhost:3306", Database: "chatdb"},
+		Gateway: Gateway{
+			Addr: ":8080",
+			Backends: Backends{
+				Token:   "localhost:50051",
+				Filter:  "localhost:50052",
+				History: "localhost:50054",
+				LLM:     "localhost:50055",
+				Auth:    "localhost:50056",
+			},
+			Timeouts: Timeouts{
+				RPC:    Duration{800 * time.Millisecond},
+				Query:  Duration{time.Second},
+				LLM:    Duration{12 * time.Second},
+				Stream: Duration{60 * time.Second},
+			},
+			RateLimit:  RateLimit{Limits: "user:3/1m", Mode: "reject", Queue: 10, MaxWait: Duration{5 * time.Second}},
+			PreReserve: 200,
+			Moderation: Moderation{Output: true, StreamChunk: 48, StreamHold: 16, Injection: Injection{Action: "warn"}},
+		},
+		Token: Token{
+			Server:       Server{Addr: ":50051", MetricsAddr: ":9051"},
+			DailyLimit:   5000,
+			HoldTTL:      Duration{120 * time.Second},
+			ReapInterval: Duration{10 * time.Second},
+		},
+		Filter: Filter{Server: Server{Addr: ":50052", MetricsAddr: ":9052"}, RulesFile: "configs/filter_rules.yaml", EventsMaxLen: 10000,
+			Store: "file", SyncInterval: Duration{30 * time.Second}},
+		History: History{Server: Server{Addr: ":50054", MetricsAddr: ":9054"}, CacheN: 40},
+		LLM: LLM{
+			Server:        Server{Addr: ":50055", MetricsAddr: ":9055"},
+			Provider:      "openai",
+			Model:         "gpt-4o-mini",
+			ContextBudget: 2000,
+		},
+		Auth: Auth{Server: Server{Addr: ":50056", MetricsAddr: ":9056"}, CacheTTL: Duration{5 * time.Minute}},
+	}
+}
+
+// Validate 校验 service 用到的配置，把所有问题一次性列出来
+func (c *Config) Validate(service string) error {
+	var v validator
+	switch service {
+	case "gateway":
+		g := c.Gateway
+		v.nonEmpty("gateway.addr", g.Addr)
+		v.nonEmpty("redis.addr", c.Redis.Addr)
+		v.nonEmpty("gateway.backends.token", g.Backends.Token)
+		v.nonEmpty("gateway.backends.filter", g.Backends.Filter)
+		v.nonEmpty("gateway.backends.history", g.Backends.History)
+		v.nonEmpty("gateway.backends.llm", g.Backends.LLM)
+		v.nonEmpty("gateway.backends.auth", g.Backends.Auth)
+		positive(&v, "gateway.timeouts.rpc", g.Timeouts.RPC.Duration)
+		positive(&v, "gateway.timeouts.query", g.Timeouts.Query.Duration)
+		positive(&v, "gateway.timeouts.llm", g.Timeouts.LLM.Duration)
+		positive(&v, "gateway.timeouts.stream", g.Timeouts.Stream.Duration)
+		positive(&v, "gateway.pre_reserve", g.PreReserve)
+		if g.Moderation.Output {
+			positive(&v, "gateway.moderation.stream_chunk", g.Moderation.StreamChunk)
+			positive(&v, "gateway.moderation.stream_hold", g.Moderation.StreamHold)
+		}
+		v.oneOf("gateway.moderation.injection.action", g.Moderation.Injection.Action, "block", "warn", "tag", "off")
+		v.oneOf("gateway.rate_limit.mode", g.RateLimit.Mode, "reject", "queue")
+		if g.RateLimit.Mode == "queue" {
+			positive(&v, "gateway.rate_limit.queue", g.RateLimit.Queue)
+			positive(&v, "gateway.rate_limit.max_wait", g.RateLimit.MaxWait.Duration)
+		}
+	case "tokenserver":
+		v.server("token", c.Token.Server)
+		v.nonEmpty("redis.addr", c.Redis.Addr)
+		if c.Token.Quotas == "" {
+			positive(&v, "token.daily_limit", c.Token.DailyLimit)
+		}
+		positive(&v, "token.hold_ttl", c.Token.HoldTTL.Duration)
+		positive(&v, "token.reap_interval", c.Token.ReapInterval.Duration)
+	case "filterserver":
+		v.server("filter", c.Filter.Server)
+		v.nonEmpty("filter.rules_file", c.Filter.RulesFile)
+		v.nonEmpty("redis.addr", c.Redis.Addr)
+		positive(&v, "filter.events_max_len", c.Filter.EventsMaxLen)
+		v.oneOf("filter.store", c.Filter.Store, "file", "mysql")
+		if c.Filter.Store == "mysql" {
+			v.mysql(c.MySQL)
+			positive(&v, "filter.sync_interval", c.Filter.SyncInterval.Duration)
+		}
+	case "historyserver":
+		v.server("history", c.History.Server)
+		v.mysql(c.MySQL)
+		v.nonEmpty("redis.addr", c.Redis.Addr)
+		positive(&v, "history.cache_n", c.History.CacheN)
+	case "llmserver":
+		l := c.LLM
+		v.server("llm", l.Server)
+		v.oneOf("llm.provider", l.Provider, "openai", "mock")
+		if l.Provider == "openai" && l.OpenAI.APIKey == "" {
+			v.add("llm.openai.api_key is empty (set OPENAI_API_KEY, or llm.provider=mock to run offline)")
+		}
+		if l.Models == "" {
+			v.nonEmpty("llm.model", l.Model)
+		}
+		positive(&v, "llm.context_budget", l.ContextBudget)
+	case "authserver":
+		v.server("auth", c.Auth.Server)
+		v.mysql(c.MySQL)
+		v.nonEmpty("redis.addr", c.Redis.Addr)
+		positive(&v, "auth.cache_ttl", c.Auth.CacheTTL.Duration)
+	default:
+		v.add("unknown service " + service)
+	}
+	return errors.Join(v.errs...)
+}
+
+type validator struct{ errs []error }
+
+func (v *validator) add(msg string) { v.errs = append(v.errs, errors.New(msg)) }
+
+func (v *validator) nonEmpty(name, s string) {
+	if s == "" {
+		v.add(name + " is empty")
+	}
+}
+
+func (v *validator) oneOf(name, s string, allowed ...string) {
+	for _, a := range allowed {
+		if s == a {
+			return
+		}
+	}
+	v.add(fmt.Sprintf("%s: %q is not one of %v", name, s, allowed))
+}
+
+func positive[T ~int | ~int32 | ~int64](v *validator, name string, n T) {
+	if n <= 0 {
+		v.add(fmt.Sprintf("%s must be positive, got %v", name, n))
+	}
+}
+
+func (v *validator) server(section string, s Server) {
+	v.nonEmpty(section+".addr", s.Addr)
+	v.nonEmpty(section+".metrics_addr", s.MetricsAddr)
+}
+
+func (v *validator) mysql(m MySQL) {
+	if m.DSN != "" {
+		if _, err := mysql.ParseDSN(m.DSN); err != nil {
+			v.add("mysql.dsn: " + err.Error())
+		}
+		return
+	}
+	v.nonEmpty("mysql.user", m.User)
+	v.nonEmpty("mysql.addr", m.Addr)
+	v.nonEmpty("mysql.database", m.Database)
+}
+
+// Duration 在配置文件与环境变量里写成 "800ms"、"5m" 这样的字符串
+type Duration struct{ time.Duration }
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) { return []byte(d.String()), nil }
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "******"
+
+// MustLoad 注册并解析命令行参数（调用前可以先定义服务自己的 flag），加载并校验 service 的配置：
+//
+//	-config PATH    配置文件（.yaml/.yml/.toml），默认取 CONFIG_FILE；都没有时只用默认值 + 环境变量
+//	-profile NAME   环境 profile，默认取 APP_ENV；会在基础文件之上叠加同目录的 config.<profile>.yaml
+//	-print-config   打印生效的配置（密钥打码）后退出
+//
+// 配置有误时打印全部问题并退出。
+func MustLoad(service string) *Config {
+	path := flag.String("config", os.Getenv("CONFIG_FILE"), "config file (.yaml, .yml or .toml); defaults to $CONFIG_FILE")
+	profile := flag.String("profile", os.Getenv("APP_ENV"), "config profile overlay, e.g. dev or prod; defaults to $APP_ENV")
+	printConfig := flag.Bool("print-config", false, "print the effective config (secrets redacted) and exit")
+	flag.Parse()
+
+	cfg, err := Load(*path, *profile)
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	verr := cfg.Validate(service)
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("config: %v", err)
+		}
+		if verr != nil {
+			fmt.Fprintf(os.Stderr, "invalid config for %s:\n%v\n", service, verr)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if verr != nil {
+		log.Fatalf("invalid config for %s: %v", service, strings.ReplaceAll(verr.Error(), "\n", "; "))
+	}
+	return cfg
+}
+
+// Load 依次叠加默认值、配置文件、profile 覆盖文件与环境变量；path 为空时跳过文件
+func Load(path, profile string) (*Config, error) {
+	cfg := Default()
+	cfg.Profile = profile
+	cfg.Sources = []string{"defaults"}
+
+	if path == "" && profile != "" {
+		return nil, fmt.Errorf("profile %q needs a base config file (-config or CONFIG_FILE)", profile)
+	}
+	if path != "" {
+		if err := cfg.decodeFile(path); err != nil {
+			return nil, err
+		}
+		if profile != "" {
+			ext := filepath.Ext(path)
+			overlay := strings.TrimSuffix(path, ext) + "." + profile + ext
+			if err := cfg.decodeFile(overlay); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var applied []string
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), &applied); err != nil {
+		return nil, err
+	}
+	if len(applied) > 0 {
+		cfg.Sources = append(cfg.Sources, "env("+strings.Join(applied, ",")+")")
+	}
+	return cfg, nil
+}
+
+// decodeFile 把文件叠加到 cfg 上：文件里没写的字段保持原值；不认识的字段报错，避免拼写错误被静默忽略
+func (c *Config) decodeFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported config format %q (want .yaml, .yml or .toml)", path, ext)
+	}
+	c.Sources = append(c.Sources, path)
+	return nil
+}
+
+var textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// applyEnv 按 env 标签用非空的环境变量覆盖字段，applied 收集实际生效的变量名
+func applyEnv(v reflect.Value, applied *[]string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Tag.Get("env")
+		if name == "" {
+			if fv.Kind() == reflect.Struct {
+				if err := applyEnv(fv, applied); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		s := os.Getenv(name)
+		if s == "" {
+			continue
+		}
+		if err := setString(fv, s); err != nil {
+			return fmt.Errorf("%s=%q: %w", name, s, err)
+		}
+		if !slices.Contains(*applied, name) {
+			*applied = append(*applied, name)
+		}
+	}
+	return nil
+}
+
+func setString(v reflect.Value, s string) error {
+	if v.Addr().Type().Implements(textUnmarshaler) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported config field type %s", v.Type())
+	}
+	return nil
+}
+
+// Print 以 YAML 输出生效的配置，secret 字段打码（DSN 只打码其中的密码）
+func (c *Config) Print(w io.Writer) error {
+	cp := *c
+	redact(reflect.ValueOf(&cp).Elem())
+	b, err := yaml.Marshal(&cp)
+	if err != nil {
+		return err
+	}
+	profile := c.Profile
+	if profile == "" {
+		profile = "(none)"
+	}
+	fmt.Fprintf(w, "# profile: %s\n# sources: %s\n", profile, strings.Join(c.Sources, " < "))
+	_, err = w.Write(b)
+	return err
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f, fv := t.Field(i), v.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		switch tag := f.Tag.Get("secret"); {
+		case fv.Kind() == reflect.Struct && tag == "":
+			redact(fv)
+		case fv.Kind() != reflect.String || fv.String() == "":
+		case tag == "dsn":
+			fv.SetString(redactDSN(fv.String()))
+		case tag != "":
+			fv.SetString(redacted)
+		}
+	}
+}
+
+func redactDSN(dsn string) string {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return redacted
+	}
+	if cfg.Passwd != "" {
+		cfg.Passwd = redacted
+	}
+	return cfg.FormatDSN()
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"slices"
+	"strconv"
+
+	pb "chatgpt-demo/chatpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxPolicyPage = 100
+	maxAuditPage  = 200
+)
+
+// adminServer 实现规则管理（FilterAdminService）。store 为 nil（filter.store=file）时所有方法返回 FailedPrecondition
+type adminServer struct {
+	pb.UnimplementedFilterAdminServiceServer
+	store *policyStore
+}
+
+var errFileStore = status.Error(codes.FailedPrecondition, "rule administration needs filter.store=mysql")
+
+func (s *adminServer) ListPolicies(ctx context.Context, in *pb.ListPoliciesRequest) (*pb.ListPoliciesReply, error) {
+	if s.store == nil {
+		return nil, errFileStore
+	}
+	limit := int(in.Limit)
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > maxPolicyPage {
+		limit = maxPolicyPage
+	}
+	latest, active, err := s.store.state(ctx, s.store.db, false)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.store.db.QueryContext(ctx, "SELECT "+policyCols+" FROM filter_policies ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reply := &pb.ListPoliciesReply{Active: nullVersion(active), Latest: nullVersion(latest)}
+	for rows.Next() {
+		p, err := scanPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		p.Active = p.Version == reply.Active
+		reply.Policies = append(reply.Policies, p)
+	}
+	return reply, rows.Err()
+}
+
+// ListRules 返回某个版本的规则（默认最新版本）；还没有任何版本时返回规则文件里的规则，version 为空
+func (s *adminServer) ListRules(ctx context.Context, in *pb.ListRulesRequest) (*pb.ListRulesReply, error) {
+	if s.store == nil {
+		return nil, errFileStore
+	}
+	var (
+		id  int64
+		b   []byte
+		err error
+	)
+	if in.Version != "" {
+		if id, err = parseVersion(in.Version); err != nil {
+			return nil, err
+		}
+	} else {
+		latest, _, err := s.store.state(ctx, s.store.db, false)
+		if err != nil {
+			return nil, err
+		}
+		id = latest.Int64
+	}
+	if id != 0 {
+		b, err = s.store.body(ctx, s.store.db, id)
+	} else {
+		b, err = os.ReadFile(s.store.file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	f, err := decodeRuleFile(b)
+	if err != nil {
+		return nil, err
+	}
+	reply := &pb.ListRulesReply{Rules: make([]*pb.FilterRule, len(f.Rules))}
+	if id != 0 {
+		reply.Version = versionName(id)
+	}
+	for i := range f.Rules {
+		reply.Rules[i] = ruleToPB(&f.Rules[i])
+	}
+	return reply, nil
+}
+
+// CreateRule 在最新版本上新增一条规则，生成新版本（不激活）；id 已存在时返回 AlreadyExists
+func (s *adminServer) CreateRule(ctx context.Context, in *pb.PutRuleRequest) (*pb.FilterPolicy, error) {
+	if s.store == nil {
+		return nil, errFileStore
+	}
+	if in.Rule.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "rule.id is required")
+	}
+	r := ruleFromPB(in.Rule)
+	return s.store.change(ctx, "rule.create", r.ID, putChange(in), func(f *ruleFile) (*ruleSpec, *ruleSpec, error) {
+		if findRule(f, r.ID) >= 0 {
+			return nil, nil, status.Errorf(codes.AlreadyExists, "rule %s already exists", r.ID)
+		}
+		f.Rules = append(f.Rules, r)
+		return nil, &r, nil
+	})
+}
+
+// UpdateRule 整条替换一条规则（未给出的字段取默认值，不与旧规则合并）
+func (s *adminServer) UpdateRule(ctx context.Context, in *pb.PutRuleRequest) (*pb.FilterPolicy, error) {
+	if s.store == nil {
+		return nil, errFileStore
+	}
+	if in.Rule.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "rule.id is required")
+	}
+	r := ruleFromPB(in.Rule)
+	return s.store.change(ctx, "rule.update", r.ID, putChange(in), func(f *ruleFile) (*ruleSpec, *ruleSpec, error) {
+		i := findRule(f, r.ID)
+		if i < 0 {
+			return nil, nil, status.Errorf(codes.NotFound, "rule %s not found", r.ID)
+		}
+		old := f.Rules[i]
+		f.Rules[i] = r
+		return &old, &r, nil
+	})
+}
+
+func (s *adminServer) DeleteRule(ctx context.Context, in *pb.DeleteRuleRequest) (*pb.FilterPolicy, error) {
+	if s.store == nil {
+		return nil, errFileStore
+	}
+	if in.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	ch := changeRequest{actor: in.Actor, actorKey: in.ActorKey, comment: in.Comment, base: in.BaseVersion}
+	return s.store.change(ctx, "rule.delete", in.Id, ch, func(f *ruleFile) (*ruleSpec, *ruleSpec, error) {
+		i := findRule(f, in.Id)
+		if i < 0 {
+			return nil, nil, status.Errorf(codes.NotFound, "rule %s not found", in.Id)
+		}
+		old := f.Rules[i]
+		f.Rules = slices.Delete(f.Rules, i, i+1)
+		return &old, nil, nil
+	})
+}
+
+// ActivatePolicy 切换生效版本（也用于回滚到旧版本），各副本经 Redis pub/sub 收到通知后切换
+func (s *adminServer) ActivatePolicy(ctx context.Context, in *pb.ActivatePolicyRequest) (*pb.FilterPolicy, error) {
+	if s.store == nil {
+		return nil, errFileStore
+	}
+	return s.store.activate(ctx, in.Version, changeRequest{actor: in.Actor, actorKey: in.ActorKey, comment: in.Comment})
+}
+
+// ListAudit 按时间倒序返回审计日志
+func (s *adminServer) ListAudit(ctx context.Context, in *pb.ListAuditRequest) (*pb.ListAuditReply, error) {
+	if s.store == nil {
+		return nil, errFileStore
+	}
+	limit := int(in.Limit)
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > maxAuditPage {
+		limit = maxAuditPage
+	}
+	where := "1=1"
+	var args []any
+	if in.Cursor != "" {
+		before, err := strconv.ParseInt(in.Cursor, 10, 64)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid cursor %q", in.Cursor)
+		}
+		where += " AND id<?"
+		args = append(args, before)
+	}
+	if in.Target != "" {
+		where += " AND target=?"
+		args = append(args, in.Target)
+	}
+	rows, err := s.store.db.QueryContext(ctx,
+		"SELECT id, UNIX_TIMESTAMP(created_at), actor, actor_key, action, target, policy_id, "+
+			"COALESCE(before_json, ''), COALESCE(after_json, ''), comment FROM filter_audit WHERE "+where+" ORDER BY id DESC LIMIT ?",
+		append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reply := &pb.ListAuditReply{}
+	for rows.Next() {
+		var id int64
+		var policy sql.NullInt64
+		e := &pb.FilterAuditEntry{}
+		if err := rows.Scan(&id, &e.CreatedAt, &e.Actor, &e.ActorKey, &e.Action, &e.Target, &policy, &e.Before, &e.After, &e.Comment); err != nil {
+			return nil, err
+		}
+		e.Id, e.Version = strconv.FormatInt(id, 10), nullVersion(policy)
+		reply.Entries = append(reply.Entries, e)
+	}
+	if len(reply.Entries) == limit {
+		reply.NextCursor = reply.Entries[limit-1].Id
+	}
+	return reply, rows.Err()
+}
+
+func putChange(in *pb.PutRuleRequest) changeRequest {
+	return changeRequest{actor: in.Actor, actorKey: in.ActorKey, comment: in.Comment, base: in.BaseVersion}
+}
+
+func findRule(f *ruleFile, id string) int {
+	return slices.IndexFunc(f.Rules, func(r ruleSpec) bool { return r.ID == id })
+}
@@ -0,0 +1,78 @@
+package main
+
+// acMachine 是按 rune 工作的 Aho-Corasick 自动机：一次扫描找出文本里所有词表项的出现位置（含重叠），
+// 耗时与文本长度 + 命中数成正比，与词表大小无关，适合上万条的词表。
+type acMachine struct {
+	nodes []acNode
+	lens  []int // 每个模式的 rune 长度
+}
+
+type acNode struct {
+	next map[rune]int32
+	fail int32
+	out  []int32 // 在此结束的模式，已并入 fail 链上的输出
+}
+
+// newAC 由模式列表构建自动机；模式下标即 findAll 回调里的 pat
+func newAC(patterns [][]rune) *acMachine {
+	m := &acMachine{nodes: []acNode{{}}, lens: make([]int, len(patterns))}
+	for i, p := range patterns {
+		m.lens[i] = len(p)
+		if len(p) == 0 {
+			continue
+		}
+		s := int32(0)
+		for _, r := range p {
+			n := m.nodes[s].next[r]
+			if n == 0 {
+				if m.nodes[s].next == nil {
+					m.nodes[s].next = map[rune]int32{}
+				}
+				m.nodes = append(m.nodes, acNode{})
+				n = int32(len(m.nodes) - 1)
+				m.nodes[s].next[r] = n
+			}
+			s = n
+		}
+		m.nodes[s].out = append(m.nodes[s].out, int32(i))
+	}
+
+	// BFS 计算 fail 指针：根的子节点指向根，其余指向父节点 fail 链上第一个有同一转移的节点
+	queue := make([]int32, 0, len(m.nodes))
+	for _, n := range m.nodes[0].next {
+		queue = append(queue, n)
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for r, n := range m.nodes[s].next {
+			f := m.nodes[s].fail
+			for f != 0 && m.nodes[f].next[r] == 0 {
+				f = m.nodes[f].fail
+			}
+			if t := m.nodes[f].next[r]; t != 0 && t != n {
+				f = t
+			} else {
+				f = 0
+			}
+			m.nodes[n].fail = f
+			m.nodes[n].out = append(m.nodes[n].out, m.nodes[f].out...)
+			queue = append(queue, n)
+		}
+	}
+	return m
+}
+
+// findAll 对每个出现调用 fn(pat, start, end)，[start, end) 为 rune 下标
+func (m *acMachine) findAll(text []rune, fn func(pat, start, end int)) {
+	s := int32(0)
+	for i, r := range text {
+		for s != 0 && m.nodes[s].next[r] == 0 {
+			s = m.nodes[s].fail
+		}
+		s = m.nodes[s].next[r]
+		for _, p := range m.nodes[s].out {
+			fn(int(p), i+1-m.lens[p], i+1)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// 规避写法语料（configs/filter_corpus.txt）与默认规则一起跑，归一化或规则退化时在 go test 里就能发现
+func TestCorpus(t *testing.T) {
+	rs, err := loadRuleset("../configs/filter_rules.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := checkCorpus(rs, "../configs/filter_corpus.txt", &out); err != nil {
+		t.Fatalf("%v\n%s", err, out.String())
+	}
+	t.Log(strings.TrimSpace(out.String()))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+
+	pb "chatgpt-demo/chatpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 一次试运行最多的文本条数
+const maxDryRunTexts = 1000
+
+// DryRun 用候选规则与当前规则分别判定一批文本并汇总拦截率，用于上线更严格的规则之前评估影响。
+// 候选规则为 policy（规则文件内容）或 candidate_version（MySQL 里保存的版本，激活之前先试运行），都不给时用当前规则。
+// 只做判定：不记录拦截事件、不计入 filter_* 指标。候选规则有误时返回 InvalidArgument（错误信息同热更新日志）。
+func (s *server) DryRun(ctx context.Context, in *pb.DryRunRequest) (*pb.DryRunReply, error) {
+	if len(in.Texts) == 0 || len(in.Texts) > maxDryRunTexts {
+		return nil, status.Errorf(codes.InvalidArgument, "want 1 to %d texts, got %d", maxDryRunTexts, len(in.Texts))
+	}
+	current := s.rules.get()
+	candidate := current
+	switch {
+	case in.Policy != "" && in.CandidateVersion != "":
+		return nil, status.Error(codes.InvalidArgument, "give policy or candidate_version, not both")
+	case in.Policy != "":
+		rs, err := parseRuleset([]byte(in.Policy))
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "policy: %v", err)
+		}
+		candidate = rs
+	case in.CandidateVersion != "":
+		if s.store == nil {
+			return nil, errFileStore
+		}
+		id, err := parseVersion(in.CandidateVersion)
+		if err != nil {
+			return nil, err
+		}
+		if candidate, err = s.store.load(ctx, id); err != nil {
+			if _, ok := status.FromError(err); !ok {
+				err = status.Error(codes.FailedPrecondition, err.Error())
+			}
+			return nil, err
+		}
+	}
+
+	reply := &pb.DryRunReply{
+		CurrentVersion:      current.version,
+		CandidateVersion:    candidate.version,
+		Total:               int32(len(in.Texts)),
+		CandidateCategories: map[string]int32{},
+		Results:             make([]*pb.DryRunResult, len(in.Texts)),
+	}
+	for i, text := range in.Texts {
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+		cur := current.judge(text, in.Direction)
+		cand := candidate.judge(text, in.Direction)
+		hits := cand.blocking
+		if in.PromoteShadow {
+			hits = append(hits, cand.shadowBlocking...)
+		}
+		res := &pb.DryRunResult{
+			Index:              int32(i),
+			CurrentBlocked:     len(cur.blocking) > 0,
+			CandidateBlocked:   len(hits) > 0,
+			Matches:            toMatches(hits),
+			InjectionScore:     float32(cand.injection.score),
+			InjectionSuspected: cand.injection.suspected,
+		}
+		if res.CandidateBlocked {
+			top := worst(res.Matches)
+			res.Category, res.Severity = top.Category, top.Severity
+			reply.CandidateBlocked++
+			reply.CandidateCategories[top.Category]++
+		}
+		if res.CurrentBlocked {
+			reply.CurrentBlocked++
+		}
+		switch {
+		case res.CandidateBlocked && !res.CurrentBlocked:
+			reply.NewlyBlocked++
+		case res.CurrentBlocked && !res.CandidateBlocked:
+			reply.NewlyAllowed++
+		}
+		reply.Results[i] = res
+	}
+	return reply, nil
+}
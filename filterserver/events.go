@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/logging"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// 拦截事件：Redis Stream，每条的 event 字段为 BlockedEvent 的 JSON，条目 ID 即事件 id
+	blockedStream  = "filter:blocked"
+	maxEventText   = 2000 // 事件里保留的原文长度（rune），超出截断
+	maxBlockedPage = 100
+	// 按租户过滤时一页可能要翻过很多别的租户的事件，单次请求最多扫描这么多条，没凑满一页也返回 next_cursor
+	maxBlockedScan = 1000
+)
+
+// events 记录被拦截的请求，供人工复核（GET /admin/moderation/blocked）
+type events struct {
+	rdb    *redis.Client
+	maxLen int64
+}
+
+// record 写入一条拦截事件；写失败只记日志，不影响判定结果。
+// 使用不随请求取消的 context：客户端断开也要留下记录。
+func (e *events) record(ctx context.Context, ev *pb.BlockedEvent) {
+	if utf8.RuneCountInString(ev.Text) > maxEventText {
+		ev.Text = string([]rune(ev.Text)[:maxEventText])
+	}
+	b, err := protojson.Marshal(ev)
+	if err != nil {
+		logging.FromContext(ctx).Error("encode blocked event", "error", err)
+		return
+	}
+	wctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 200*time.Millisecond)
+	defer cancel()
+	err = e.rdb.XAdd(wctx, &redis.XAddArgs{
+		Stream: blockedStream,
+		MaxLen: e.maxLen,
+		Approx: true,
+		Values: []any{"event", b},
+	}).Err()
+	if err != nil {
+		logging.FromContext(ctx).Warn("record blocked event", "error", err)
+	}
+}
+
+// RecordBlocked 补记网关拦下的请求（Filter 本身放行，如按租户策略拦截的疑似提示词注入）；
+// 与 Filter 的拦截事件一样遮盖原文里的个人敏感信息
+func (s *server) RecordBlocked(ctx context.Context, in *pb.BlockedEvent) (*pb.RecordBlockedReply, error) {
+	in.Id = ""
+	if in.CreatedAt == 0 {
+		in.CreatedAt = time.Now().UnixMilli()
+	}
+	if in.RequestId == "" {
+		in.RequestId = logging.RequestID(ctx)
+	}
+	in.Text = obscure(in.Text, s.rules.get().judge(in.Text, in.Direction).personal)
+	s.events.record(ctx, in)
+	return &pb.RecordBlockedReply{Ok: true}, nil
+}
+
+// ListBlocked 按时间倒序返回拦截事件；tenant_id 非空时只看该租户用户的事件
+func (s *server) ListBlocked(ctx context.Context, in *pb.ListBlockedRequest) (*pb.ListBlockedReply, error) {
+	limit := int(in.Limit)
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > maxBlockedPage {
+		limit = maxBlockedPage
+	}
+	if strings.Contains(in.TenantId, "/") {
+		return nil, status.Error(codes.InvalidArgument, "tenant_id must not contain '/'")
+	}
+	var prefix string
+	if in.TenantId != "" {
+		prefix = in.TenantId + "/"
+	}
+
+	// 游标为上一页最后一条的 ID，"(" 表示不含该条
+	end := "+"
+	if in.Cursor != "" {
+		end = "(" + in.Cursor
+	}
+	out := &pb.ListBlockedReply{}
+	for scanned := 0; scanned < maxBlockedScan; {
+		msgs, err := s.events.rdb.XRevRangeN(ctx, blockedStream, end, "-", int64(limit)).Result()
+		if err != nil {
+			if strings.Contains(err.Error(), "Invalid stream ID") {
+				return nil, status.Errorf(codes.InvalidArgument, "bad cursor %q", in.Cursor)
+			}
+			return nil, err
+		}
+		for _, m := range msgs {
+			scanned++
+			end = "(" + m.ID
+			raw, _ := m.Values["event"].(string)
+			ev := &pb.BlockedEvent{}
+			if protojson.Unmarshal([]byte(raw), ev) != nil {
+				continue
+			}
+			if prefix != "" && !strings.HasPrefix(ev.UserId, prefix) {
+				continue
+			}
+			ev.Id = m.ID
+			out.Events = append(out.Events, ev)
+			if len(out.Events) == limit {
+				out.NextCursor = m.ID
+				return out, nil
+			}
+		}
+		if len(msgs) < limit {
+			return out, nil // 已到最早一条
+		}
+		out.NextCursor = msgs[len(msgs)-1].ID
+	}
+	return out, nil
+}
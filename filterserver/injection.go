@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"unicode/utf8"
+)
+
+// injectionSpec 是规则文件里提示词注入评分的配置（只对用户输入评分）：
+//
+//	injection:
+//	  threshold: 0.5                 # 分数达到阈值即为疑似注入，网关按租户策略拦截/警告/标记
+//	  signals:                       # 覆盖内置信号的权重（0 关闭），或追加正则；新信号须给出 weight
+//	    jailbreak: {weight: 0.7, patterns: ['\bevil confidant\b']}
+//	    encoded:   {weight: 0}
+type injectionSpec struct {
+	Threshold float64                        `yaml:"threshold,omitempty"`
+	Signals   map[string]injectionSignalSpec `yaml:"signals,omitempty"`
+}
+
+type injectionSignalSpec struct {
+	Weight   *float64 `yaml:"weight,omitempty"`
+	Patterns []string `yaml:"patterns,omitempty"`
+}
+
+// injectionSignal 是一类注入特征：patterns 在归一化文本上匹配（全角、形近字、零宽字符已处理），
+// detect 看原文的结构特征。一类信号命中多处只计一次
+type injectionSignal struct {
+	name     string
+	weight   float64
+	patterns []*regexp.Regexp
+	detect   func(text string) (start, end int, ok bool)
+}
+
+const defaultInjectionThreshold = 0.5
+
+// 内置信号，权重可在规则文件里调整
+var injectionSignals = []injectionSignal{
+	// 要求忽略/覆盖之前的指令
+	{name: "override", weight: 0.6, patterns: mustCompileAll(
+		`\b(ignore|disregard|forget|skip|override|bypass)\b.{0,30}\b(previous|prior|above|earlier|preceding|all|any|your|the|system)\b.{0,20}\b(instructions?|prompts?|rules|directions|guidelines|context)\b`,
+		`\bnew (instructions|rules|system prompt)\s*:`,
+		`\bfrom now on,? you (will|must|are|shall)\b`,
+		`(忽略|无视|忘记|忘掉|不要理会|跳过)掉?.{0,10}(之前|以上|上面|前面|先前|上述|所有|全部|系统)的?.{0,6}(指令|指示|规则|提示|设定|要求)`,
+		`从现在(开始|起)[，,]?\s*你(是|必须|将|要|只)`,
+	)},
+	// 套取系统提示词
+	{name: "exfiltration", weight: 0.6, patterns: mustCompileAll(
+		`\b(reveal|show|print|repeat|display|output|tell me|give me|dump|leak)\b.{0,30}\b(system|initial|original|hidden|secret|developer)\s+(prompt|instructions?|message|rules)\b`,
+		`\bwhat('s| is| are| were) your (system |initial |original |hidden |secret )?(prompt|instructions)\b`,
+		`\brepeat (everything|the (text|words|lines)|all( of)?( the)? text) (above|before)\b`,
+		`\b(your|the) (prompt|instructions) (verbatim|word for word)\b`,
+		`(输出|显示|打印|告诉我|重复|泄露|透露|给我看|复述).{0,10}(系统提示|系统指令|系统消息|初始指令|提示词|系统设定)`,
+	)},
+	// 角色扮演类越狱：DAN、开发者模式、“假装你没有任何限制”
+	{name: "jailbreak", weight: 0.6, patterns: mustCompileAll(
+		`\bdo anything now\b|\bdan mode\b|\byou are (now )?dan\b`,
+		`\b(developer|god|jailbreak|unrestricted|unfiltered|uncensored) mode\b`,
+		`\b(pretend|act as|roleplay as|imagine (that )?you are|you are now)\b.{0,60}\b(no|without|free (from|of)|not bound by|ignore)\b.{0,20}\b(restrictions?|rules|limits|limitations|filters?|guidelines|censorship|polic(y|ies)|ethics)\b`,
+		`\byou are no longer\b.{0,20}\b(an? )?(ai|assistant|chatgpt|bound|restricted)\b`,
+		`\b(stay in character|never break character)\b`,
+		`(假装|扮演|想象你是|你现在是).{0,30}(没有|不受|无视|摆脱|不用遵守).{0,10}(限制|约束|规则|审查|道德|政策)`,
+		`(开发者|上帝|越狱)模式|越狱`,
+	)},
+	// 伪造的对话模板与角色标记，试图让模型把用户输入当成系统消息
+	{name: "role_marker", weight: 0.4, patterns: mustCompileAll(
+		`<\|(im_start|im_end|system|assistant|user|endoftext)\|>`,
+		`\[/?(inst|sys)\]|<</?sys>>|</?(system|assistant)>`,
+		`(?m)^\s*#{0,3}\s*(system|assistant)\s*:`,
+	)},
+	// 长段 base64，常用来夹带不想被看到的指令
+	{name: "encoded", weight: 0.2, patterns: mustCompileAll(`[a-z0-9+/]{60,}={0,2}`)},
+	// 藏在不可见字符里的内容：成段的零宽/标签字符、方向控制符
+	{name: "hidden", weight: 0.3, detect: hiddenText},
+}
+
+func mustCompileAll(exprs ...string) []*regexp.Regexp {
+	out := make([]*regexp.Regexp, len(exprs))
+	for i, e := range exprs {
+		out[i] = regexp.MustCompile("(?i)" + e)
+	}
+	return out
+}
+
+// hiddenText 找不可见字符：方向控制符出现即算，其他（ZWJ/ZWNJ 除外）累计 3 个以上才算；返回第一个的位置
+func hiddenText(text string) (int, int, bool) {
+	first, n, i := -1, 0, 0
+	for _, r := range text {
+		switch {
+		case '\u202a' <= r && r <= '\u202e' || '\u2066' <= r && r <= '\u2069':
+			return i, i + 1, true
+		case invisible(r) && r != '\u200c' && r != '\u200d':
+			if first < 0 {
+				first = i
+			}
+			n++
+		}
+		i++
+	}
+	return first, first + 1, n >= 3
+}
+
+// injectionScorer 是编译好的评分配置
+type injectionScorer struct {
+	threshold float64
+	signals   []injectionSignal
+}
+
+// compileInjection 在内置信号上叠加规则文件里的配置
+func compileInjection(spec injectionSpec) (*injectionScorer, error) {
+	s := &injectionScorer{threshold: spec.Threshold}
+	if s.threshold == 0 {
+		s.threshold = defaultInjectionThreshold
+	}
+	if s.threshold < 0 || s.threshold > 1 {
+		return nil, fmt.Errorf("injection.threshold: %v is not in (0, 1]", spec.Threshold)
+	}
+	byName := map[string]int{}
+	for _, sig := range injectionSignals {
+		byName[sig.name] = len(s.signals)
+		sig.patterns = slices.Clone(sig.patterns)
+		s.signals = append(s.signals, sig)
+	}
+	for _, name := range slices.Sorted(maps.Keys(spec.Signals)) {
+		c := spec.Signals[name]
+		i, ok := byName[name]
+		if !ok {
+			if c.Weight == nil || len(c.Patterns) == 0 {
+				return nil, fmt.Errorf("injection.signals.%s: a new signal needs weight and patterns", name)
+			}
+			i = len(s.signals)
+			s.signals = append(s.signals, injectionSignal{name: name})
+		}
+		sig := &s.signals[i]
+		if c.Weight != nil {
+			if *c.Weight < 0 || *c.Weight > 1 {
+				return nil, fmt.Errorf("injection.signals.%s: weight %v is not in [0, 1]", name, *c.Weight)
+			}
+			sig.weight = *c.Weight
+		}
+		for _, p := range c.Patterns {
+			re, err := regexp.Compile("(?i)" + p)
+			if err != nil {
+				return nil, fmt.Errorf("injection.signals.%s: %w", name, err)
+			}
+			sig.patterns = append(sig.patterns, re)
+		}
+	}
+	return s, nil
+}
+
+// signalHit 是触发的一类信号及其第一处位置（原文 rune 下标）
+type signalHit struct {
+	signal     *injectionSignal
+	start, end int
+}
+
+// injectionResult 是一段用户输入的注入风险
+type injectionResult struct {
+	score     float64
+	suspected bool
+	hits      []signalHit
+}
+
+// score 对文本评分：各信号独立，分数 = 1 − ∏(1 − weight)，只有一类信号时就是它的权重，多类叠加逐渐逼近 1
+func (s *injectionScorer) score(text string, base *normText) injectionResult {
+	var res injectionResult
+	norm := string(base.r)
+	miss := 1.0
+	for i := range s.signals {
+		sig := &s.signals[i]
+		if sig.weight == 0 {
+			continue
+		}
+		h, ok := sig.find(text, norm, base)
+		if !ok {
+			continue
+		}
+		res.hits = append(res.hits, h)
+		miss *= 1 - sig.weight
+	}
+	res.score = 1 - miss
+	res.suspected = res.score >= s.threshold
+	return res
+}
+
+func (sig *injectionSignal) find(text, norm string, base *normText) (signalHit, bool) {
+	for _, re := range sig.patterns {
+		loc := re.FindStringIndex(norm)
+		if loc == nil || loc[0] == loc[1] {
+			continue
+		}
+		start := utf8.RuneCountInString(norm[:loc[0]])
+		from, to := base.span(start, start+utf8.RuneCountInString(norm[loc[0]:loc[1]]))
+		return signalHit{signal: sig, start: from, end: to}, true
+	}
+	if sig.detect != nil {
+		if start, end, ok := sig.detect(text); ok {
+			return signalHit{signal: sig, start: start, end: end}, true
+		}
+	}
+	return signalHit{}, false
+}
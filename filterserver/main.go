@@ -1,44 +1,280 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"flag"
 	"log"
+	"log/slog"
 	"net"
+	"os"
+	"slices"
 	"strings"
+	"time"
 
 	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/config"
+	"chatgpt-demo/logging"
+	"chatgpt-demo/metrics"
+	"chatgpt-demo/tracing"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 )
 
 type server struct {
 	pb.UnimplementedFilterServiceServer
+	rules  *rules
+	events *events
+	store  *policyStore // filter.store=file 时为 nil
 }
 
-// very simple filter: block if contains "foo" or "badword" (case-insensitive)
+var (
+	filterBlocked = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "filter_blocked_total",
+		Help: "Texts rejected by the filter, by direction (input, output) and category of the most severe match.",
+	}, []string{"direction", "category"})
+	filterRedacted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "filter_redacted_total",
+		Help: "Matches redacted from model replies, by category.",
+	}, []string{"category"})
+	filterPII = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "filter_pii_total",
+		Help: "Personal data found in texts, by type and the action taken (mask, redact, block).",
+	}, []string{"type", "action"})
+	filterShadow = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "filter_shadow_total",
+		Help: "Texts let through by the enforced rules that shadow-mode rules would have blocked or redacted, by direction, action and category.",
+	}, []string{"direction", "action", "category"})
+	injectionScore = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "filter_injection_score",
+		Help:    "Prompt-injection risk scores of user input, for tuning injection.threshold.",
+		Buckets: []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1},
+	})
+)
+
+// Filter 按当前规则集（词表整词/子串匹配、正则、白名单、个人敏感信息策略）判定文本是否放行。
+// 拦截时返回全部命中（规则、分类、严重程度、原文中的位置），并记录拦截事件供人工复核。
+// 放行时：用户输入（INPUT）按 mask 策略把个人敏感信息替换成占位符，并压缩空白；
+// 模型回复（OUTPUT）保留原文格式，只把 redact 的命中逐字换成 *。
+// 用户输入另外返回提示词注入评分，不影响 allowed；影子模式的规则只记日志与指标（见 shadow）。
+// partial（流式回复中途的审核）放行时不记指标与影子日志，由最后一次全文审核记；
+// history（显式给出的上下文）不计入评分分布。
 func (s *server) Filter(ctx context.Context, in *pb.FilterRequest) (*pb.FilterReply, error) {
-	raw := strings.TrimSpace(in.Text)
-	low := strings.ToLower(raw)
+	rs := s.rules.get()
+	output := in.Direction == pb.FilterDirection_OUTPUT
+	v := rs.judge(in.Text, in.Direction)
+	blocking, redacted, masked, personal := v.blocking, v.redacted, v.masked, v.personal
+	matches := toMatches(blocking)
+	// 流式中途的审核不计放行时的指标，由最后一次全文审核记；拦截时流即中断，照常记
+	if !in.Partial || len(matches) > 0 {
+		for _, h := range v.pii {
+			filterPII.WithLabelValues(h.typ, v.policy[h.typ].Action).Inc()
+		}
+	}
+	if len(matches) == 0 && !in.Partial {
+		shadow(ctx, rs, in.Direction, v)
+	}
 
-	allowed := !(strings.Contains(low, "foo") || strings.Contains(low, "badword"))
+	reply := &pb.FilterReply{
+		Allowed:        len(matches) == 0,
+		RulesetVersion: rs.version,
+		Matches:        matches,
+	}
+	if !output {
+		inj := v.injection
+		reply.InjectionScore, reply.InjectionSuspected = float32(inj.score), inj.suspected
+		for _, h := range inj.hits {
+			reply.InjectionSignals = append(reply.InjectionSignals, &pb.InjectionSignal{
+				Signal: h.signal.name, Weight: float32(h.signal.weight), Start: int32(h.start), End: int32(h.end),
+			})
+		}
+		if !in.History {
+			injectionScore.Observe(inj.score)
+		}
+		if inj.suspected {
+			names := make([]string, len(inj.hits))
+			for i, h := range inj.hits {
+				names[i] = h.signal.name
+			}
+			logging.FromContext(ctx).Info("prompt injection suspected", "score", inj.score, "signals", names, "ruleset_version", rs.version)
+		}
+	}
+	if reply.Allowed && output {
+		reply.Matches = toMatches(redacted)
+		reply.Cleaned = obscure(in.Text, redacted)
+		if !in.Partial {
+			for _, m := range reply.Matches {
+				filterRedacted.WithLabelValues(m.Category).Inc()
+			}
+		}
+		return reply, nil
+	}
+	if reply.Allowed {
+		text := in.Text
+		if len(masked) > 0 {
+			var placeholders []string
+			text, placeholders = maskPII(in.Text, masked, in.PiiOffsets)
+			for i, h := range masked {
+				e := &pb.PiiEntity{Type: h.typ, Placeholder: placeholders[i], Start: int32(h.start), End: int32(h.end)}
+				if rs.pii[h.typ].Restore {
+					e.Value = h.value
+				}
+				reply.Pii = append(reply.Pii, e)
+			}
+		}
+		// 清洗：去掉不可见字符，把多余空白压成一个空格；其余字符保持用户原样（归一化只用于匹配）
+		reply.Cleaned = strings.Join(strings.Fields(stripInvisible(text)), " ")
+		return reply, nil
+	}
 
-	// 简单清洗：把多余空白压成一个空格
-	cleaned := strings.Join(strings.Fields(raw), " ")
+	top := worst(matches)
+	reply.Category, reply.Severity = top.Category, top.Severity
+	dir := strings.ToLower(in.Direction.String())
+	filterBlocked.WithLabelValues(dir, top.Category).Inc()
+	logging.FromContext(ctx).Info("text blocked", "direction", dir, "ruleset_version", rs.version, "rules", ruleIDs(matches),
+		"category", top.Category, "severity", top.Severity)
+	s.events.record(ctx, &pb.BlockedEvent{
+		CreatedAt:      time.Now().UnixMilli(),
+		UserId:         in.UserId,
+		RequestId:      logging.RequestID(ctx),
+		RulesetVersion: rs.version,
+		Category:       top.Category,
+		Severity:       top.Severity,
+		Text:           obscure(in.Text, personal), // 复核记录里也不留个人敏感信息
+		Matches:        matches,
+		Direction:      in.Direction,
+	})
+	return reply, nil
+}
 
-	return &pb.FilterReply{
-		Allowed: allowed,
-		Cleaned: cleaned,
-	}, nil
+// shadow 记录影子模式规则的结果：这段文本若规则转为 enforce 会被拦截（或回复被遮盖）时记日志与 filter_shadow_total。
+// 只在生效的规则放行时调用，已被拦截的文本不会因为影子规则而改变结果
+func shadow(ctx context.Context, rs *ruleset, d pb.FilterDirection, v verdict) {
+	action, hits := "block", v.shadowBlocking
+	if len(hits) == 0 {
+		action, hits = "redact", v.shadowRedacted
+	}
+	if len(hits) == 0 {
+		return
+	}
+	ms := toMatches(hits)
+	top := worst(ms)
+	dir := strings.ToLower(d.String())
+	filterShadow.WithLabelValues(dir, action, top.Category).Inc()
+	logging.FromContext(ctx).Info("shadow rules matched", "direction", dir, "action", action, "ruleset_version", rs.version,
+		"rules", ruleIDs(ms), "category", top.Category, "severity", top.Severity)
+}
+
+// ruleIDs 返回命中涉及的规则 id（去重，保持顺序）
+func ruleIDs(ms []*pb.FilterMatch) []string {
+	ids := make([]string, 0, len(ms))
+	for _, m := range ms {
+		if !slices.Contains(ids, m.RuleId) {
+			ids = append(ids, m.RuleId)
+		}
+	}
+	return ids
+}
+
+// toMatches 把命中按位置排序并去重（同一规则里重复的词会在同一位置命中多次）
+func toMatches(hits []hit) []*pb.FilterMatch {
+	slices.SortStableFunc(hits, func(a, b hit) int {
+		return cmp.Or(cmp.Compare(a.start, b.start), cmp.Compare(a.end, b.end), cmp.Compare(a.rule.id, b.rule.id))
+	})
+	var out []*pb.FilterMatch
+	for i, h := range hits {
+		if i > 0 && h.rule == hits[i-1].rule && h.start == hits[i-1].start && h.end == hits[i-1].end {
+			continue
+		}
+		out = append(out, &pb.FilterMatch{
+			RuleId: h.rule.id, Category: h.rule.category, Severity: h.rule.severity,
+			Start: int32(h.start), End: int32(h.end),
+		})
+	}
+	return out
+}
+
+// worst 返回最严重的命中；同等严重时取最靠前的
+func worst(ms []*pb.FilterMatch) *pb.FilterMatch {
+	top := ms[0]
+	for _, m := range ms[1:] {
+		if severities[m.Severity] > severities[top.Severity] {
+			top = m
+		}
+	}
+	return top
 }
 
 func main() {
-	lis, err := net.Listen("tcp", ":50052")
+	logging.Init("filterserver")
+	// 用语料检查当前规则（规避写法是否都能拦下、正常文本是否误伤）：go run ./filterserver -check configs/filter_corpus.txt
+	check := flag.String("check", "", "run a corpus file against the rules, print mismatches and exit")
+	cfg := config.MustLoad("filterserver")
+	if *check != "" {
+		rs, err := loadRuleset(cfg.Filter.RulesFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := checkCorpus(rs, *check, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	shutdown, err := tracing.Init(context.Background(), "filterserver")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
+	// Redis：拦截事件、规则版本的激活通知
+	rdb := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr})
+	tracing.InstrumentRedis(rdb)
+	metrics.RedisPool(rdb)
+
+	var (
+		rules *rules
+		store *policyStore
+	)
+	if cfg.Filter.Store == "mysql" {
+		db, err := tracing.OpenMySQL(cfg.MySQL.DataSource())
+		if err != nil {
+			log.Fatal(err)
+		}
+		metrics.DBPool(db, "chatdb")
+		store = &policyStore{db: db, rdb: rdb, file: cfg.Filter.RulesFile}
+		if rules, err = newRules(context.Background(), store.loadActive); err != nil {
+			log.Fatal(err)
+		}
+		go rules.follow(context.Background(), store, cfg.Filter.SyncInterval.Duration)
+	} else {
+		rules, err = newRules(context.Background(), func(context.Context) (*ruleset, error) {
+			return loadRuleset(cfg.Filter.RulesFile)
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		go rules.watch(context.Background(), cfg.Filter.RulesFile)
+	}
+
+	lis, err := net.Listen("tcp", cfg.Filter.Addr)
 	if err != nil {
 		log.Fatal(err)
 	}
-	s := grpc.NewServer()
-	pb.RegisterFilterServiceServer(s, &server{})
-	log.Println("Filter service listening :50052")
+	metrics.Serve(cfg.Filter.MetricsAddr)
+
+	s := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(logging.UnaryServerInterceptor, metrics.UnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(logging.StreamServerInterceptor, metrics.StreamServerInterceptor),
+	)
+	pb.RegisterFilterServiceServer(s, &server{rules: rules, events: &events{rdb: rdb, maxLen: cfg.Filter.EventsMaxLen}, store: store})
+	pb.RegisterFilterAdminServiceServer(s, &adminServer{store: store})
+	slog.Info("filter service listening", "addr", cfg.Filter.Addr, "store", cfg.Filter.Store, "rules", cfg.Filter.RulesFile,
+		"ruleset_version", rules.get().version, "redis", cfg.Redis.Addr)
 	if err := s.Serve(lis); err != nil {
 		log.Fatal(err)
 	}
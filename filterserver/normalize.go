@@ -0,0 +1,157 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normText 是归一化后的文本。from/to 记录每个字符来自原文的哪一段（rune 下标，[from, to)），
+// 在归一化文本上找到的命中据此映射回原文，返回给客户端的位置始终对应用户提交的字符。
+type normText struct {
+	r        []rune
+	from, to []int
+}
+
+func (n *normText) add(r rune, from, to int) {
+	n.r = append(n.r, r)
+	n.from = append(n.from, from)
+	n.to = append(n.to, to)
+}
+
+// span 把归一化文本上的 [start, end) 映射回原文
+func (n *normText) span(start, end int) (int, int) {
+	return n.from[start], n.to[end-1]
+}
+
+// normalize 生成匹配用的文本（正则在它上面跑）：
+//
+//	NFKD 兼容分解：全角 ＡＢＣ、连字 ﬁ、圈号 ① 等变回普通字符，带声调的字母拆成“字母 + 组合符”
+//	去掉组合符与不可见字符：é → e，零宽空格/连接符、软连字符、方向控制符、BOM 等直接丢弃
+//	小写 + 形近字折叠：西里尔 о、希腊 ο 等折成拉丁字母
+func normalize(s string) *normText {
+	n := &normText{r: make([]rune, 0, len(s))}
+	var it norm.Iter
+	it.InitString(norm.NFKD, s)
+	pos, idx := 0, 0
+	for !it.Done() {
+		seg := it.Next()
+		end := it.Pos()
+		from, to := idx, idx+utf8.RuneCountInString(s[pos:end])
+		for _, r := range string(seg) {
+			if unicode.Is(unicode.Mn, r) || invisible(r) {
+				continue
+			}
+			r = unicode.ToLower(r)
+			if c, ok := confusables[r]; ok {
+				r = c
+			}
+			n.add(r, from, to)
+		}
+		pos, idx = end, to
+	}
+	return n
+}
+
+// invisible 判断字符是否不可见：格式控制符（零宽字符、方向控制、软连字符、BOM）与常被当空白用的填充字符
+func invisible(r rune) bool {
+	return unicode.Is(unicode.Cf, r) || r == '\u115f' || r == '\u1160' || r == '\u3164' || r == '\u2800' || r == '\uffa0'
+}
+
+// loosen 在 normalize 的基础上生成词表用的宽松文本：
+//
+//	合并单字间的分隔符：b a d w o r d、b.a.d、法 轮 功 → 连写（两侧都是单字时才合并，"I am a dog" 不受影响）
+//	leetspeak：含字母的词里 4→a、3→e、0→o、1→i、5→s、7→t，夹在字母数字之间的 @→a、$→s、!→i、|→l
+func (n *normText) loosen() *normText {
+	// 切成“词”和“分隔符”交替的段；夹在字母数字之间的 leet 符号算词的一部分（b@d 是一个词）
+	type seg struct {
+		start, end int
+		word       bool
+	}
+	var segs []seg
+	for i := range n.r {
+		w := isWordRune(n.r[i]) || (leetSymbols[n.r[i]] != 0 && i > 0 && i+1 < len(n.r) && isWordRune(n.r[i-1]) && isWordRune(n.r[i+1]))
+		if len(segs) > 0 && segs[len(segs)-1].word == w {
+			segs[len(segs)-1].end = i + 1
+			continue
+		}
+		segs = append(segs, seg{i, i + 1, w})
+	}
+
+	// 单字之间的分隔符去掉，两侧的字并成一个词；leet 按并好的词判断（b a d w 0 r d 里的 0 也要换）
+	joined := func(k int) bool {
+		return !segs[k].word && k > 0 && k+1 < len(segs) && segs[k-1].end-segs[k-1].start == 1 && segs[k+1].end-segs[k+1].start == 1
+	}
+	out := &normText{r: make([]rune, 0, len(n.r))}
+	for k := 0; k < len(segs); k++ {
+		sg := segs[k]
+		if !sg.word {
+			if !joined(k) {
+				for i := sg.start; i < sg.end; i++ {
+					out.add(n.r[i], n.from[i], n.to[i])
+				}
+			}
+			continue
+		}
+		group := []seg{sg}
+		for k+2 < len(segs) && joined(k+1) {
+			k += 2
+			group = append(group, segs[k])
+		}
+		leet := false
+		for _, g := range group {
+			for i := g.start; i < g.end; i++ {
+				leet = leet || unicode.IsLetter(n.r[i])
+			}
+		}
+		for _, g := range group {
+			for i := g.start; i < g.end; i++ {
+				r := n.r[i]
+				if c := leetSymbols[r]; c != 0 {
+					r = c
+				} else if c := leetDigits[r]; c != 0 && leet {
+					r = c
+				}
+				out.add(r, n.from[i], n.to[i])
+			}
+		}
+	}
+	return out
+}
+
+// canonical 是规则词表与白名单的归一化，和文本走同一套流程，两边才能对上
+func canonical(s string) []rune {
+	return normalize(s).loosen().r
+}
+
+var (
+	leetDigits  = map[rune]rune{'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't'}
+	leetSymbols = map[rune]rune{'@': 'a', '$': 's', '!': 'i', '|': 'l'}
+)
+
+// confusables 是常见的形近字（已是小写）：西里尔、希腊字母里和拉丁字母长得一样的。
+// 只收录几乎不会误伤的一对一映射，完整列表见 Unicode TR39 confusables.txt
+var confusables = map[rune]rune{
+	// 西里尔
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c',
+	'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's', 'і': 'i', 'ј': 'j', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	'ɡ': 'g', 'һ': 'h', 'ӏ': 'l',
+	// 希腊
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't',
+	'υ': 'u', 'χ': 'x', 'ω': 'w',
+	// 拉丁扩展里的形近字
+	'ı': 'i', 'ł': 'l', 'ø': 'o', 'đ': 'd', 'ħ': 'h',
+}
+
+// stripInvisible 去掉 cleaned 里的不可见字符，其余字符（全角、形近字等）保持用户原样；
+// ZWJ/ZWNJ 保留：emoji 组合序列与波斯文、印地文的正常书写要用到
+func stripInvisible(s string) string {
+	return strings.Map(func(r rune) rune {
+		if invisible(r) && r != '\u200c' && r != '\u200d' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// piiSpec 是规则文件里一种个人敏感信息的处理策略（模型回复的 output.pii 用 redact 代替 mask，逐字换成 *，不能 restore）：
+//
+//	pii:
+//	  phone:     {action: mask, restore: true}  # 替换成 [PHONE_1]，网关可在回复里换回原值
+//	  bank_card: {action: block}                # 整条拦截（category: pii，severity 默认 high）
+//	  api_key:   {action: block, severity: critical}
+//
+// 没列出的类型不检测
+type piiSpec struct {
+	Action   string `yaml:"action,omitempty"` // mask（output 为 redact）| block | off
+	Restore  bool   `yaml:"restore,omitempty"`
+	Severity string `yaml:"severity,omitempty"`
+}
+
+// piiDetector 是一种个人敏感信息：正则找候选，valid 再做校验（校验位、Luhn、日期）
+type piiDetector struct {
+	typ    string
+	label  string // 占位符里的名字
+	re     *regexp.Regexp
+	digits bool // 两侧不能紧挨字母/数字，避免从更长的编号里截出一段
+	valid  func(s string) bool
+}
+
+// 按顺序检测，和先认定的重叠的候选丢弃：邮箱里的数字不再算手机号，身份证号不再算银行卡
+var piiDetectors = []piiDetector{
+	{typ: "api_key", label: "API_KEY", re: regexp.MustCompile(
+		`\b(?:sk-(?:proj-|ant-)?[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,}|xox[abprs]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_-]{35})`)},
+	{typ: "email", label: "EMAIL", re: regexp.MustCompile(
+		`[A-Za-z0-9._%+-]+@[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?)*\.[A-Za-z]{2,}`)},
+	{typ: "cn_id", label: "CN_ID", re: regexp.MustCompile(`\d{17}[\dXx]`), digits: true, valid: validCNID},
+	{typ: "bank_card", label: "BANK_CARD", re: regexp.MustCompile(`\d{13,19}|\d{4}(?:[- ]\d{4}){2,3}(?:[- ]\d{1,3})?`), digits: true, valid: validCard},
+	{typ: "phone", label: "PHONE", re: regexp.MustCompile(`(?:\+?86[- ]?)?1[3-9]\d(?:[- ]?\d{4}){2}`), digits: true},
+}
+
+var piiTypes = func() map[string]bool {
+	m := map[string]bool{}
+	for _, d := range piiDetectors {
+		m[d.typ] = true
+	}
+	return m
+}()
+
+// piiHit 是一处个人敏感信息；[start, end) 为 rune 下标
+type piiHit struct {
+	typ        string
+	value      string
+	start, end int
+}
+
+// detectPII 找出文本里策略关心的个人敏感信息，按位置排序
+func detectPII(text string, policy map[string]piiSpec) []piiHit {
+	var taken [][2]int // 已认定的字节区间
+	var hits []piiHit
+	for _, d := range piiDetectors {
+		if a := policy[d.typ].Action; a == "" || a == "off" {
+			continue
+		}
+	next:
+		for _, loc := range d.re.FindAllStringIndex(text, -1) {
+			s := text[loc[0]:loc[1]]
+			if d.digits && !isolated(text, loc[0], loc[1]) {
+				continue
+			}
+			if d.valid != nil && !d.valid(s) {
+				continue
+			}
+			for _, t := range taken {
+				if loc[0] < t[1] && t[0] < loc[1] {
+					continue next
+				}
+			}
+			taken = append(taken, [2]int{loc[0], loc[1]})
+			start := utf8.RuneCountInString(text[:loc[0]])
+			hits = append(hits, piiHit{typ: d.typ, value: s, start: start, end: start + utf8.RuneCountInString(s)})
+		}
+	}
+	slices.SortFunc(hits, func(a, b piiHit) int { return cmp.Compare(a.start, b.start) })
+	return hits
+}
+
+// isolated 判断 [i, j) 两侧不是 ASCII 字母/数字
+func isolated(s string, i, j int) bool {
+	return (i == 0 || !isASCIIAlnum(s[i-1])) && (j == len(s) || !isASCIIAlnum(s[j]))
+}
+
+func isASCIIAlnum(b byte) bool {
+	return '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+// validCNID 校验 18 位居民身份证号：出生日期合法 + GB 11643 校验位（ISO 7064 MOD 11-2）
+func validCNID(s string) bool {
+	if _, err := time.Parse("20060102", s[6:14]); err != nil || s[6:8] < "18" || s[6:8] > "20" {
+		return false
+	}
+	weights := [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i, w := range weights {
+		sum += int(s[i]-'0') * w
+	}
+	return strings.ToUpper(s[17:]) == string("10X98765432"[sum%11])
+}
+
+// validCard 校验银行卡号：去掉分隔符后 13–19 位且通过 Luhn
+func validCard(s string) bool {
+	d := strings.NewReplacer(" ", "", "-", "").Replace(s)
+	if len(d) < 13 || len(d) > 19 {
+		return false
+	}
+	sum := 0
+	for i := range len(d) {
+		n := int(d[len(d)-1-i] - '0')
+		if i%2 == 1 {
+			if n *= 2; n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+	}
+	return sum%10 == 0
+}
+
+// maskPII 把 hits 替换成占位符，返回替换后的文本与每处对应的占位符；同一类型的同一值复用同一占位符。
+// offsets 为已经用掉的编号（标签 → 最大编号，见 FilterRequest.pii_offsets），编号接着往下排
+func maskPII(text string, hits []piiHit, offsets map[string]int32) (string, []string) {
+	labels := map[string]string{}
+	count := map[string]int{}
+	for _, d := range piiDetectors {
+		labels[d.typ] = d.label
+		count[d.typ] = int(offsets[d.label])
+	}
+	seen := map[string]string{}
+	placeholders := make([]string, len(hits))
+	rs := []rune(text)
+	var b strings.Builder
+	pos := 0
+	for i, h := range hits {
+		key := h.typ + "\x00" + normalizePII(h.typ, h.value)
+		ph, ok := seen[key]
+		if !ok {
+			count[h.typ]++
+			ph = fmt.Sprintf("[%s_%d]", labels[h.typ], count[h.typ])
+			seen[key] = ph
+		}
+		placeholders[i] = ph
+		b.WriteString(string(rs[pos:h.start]))
+		b.WriteString(ph)
+		pos = h.end
+	}
+	b.WriteString(string(rs[pos:]))
+	return b.String(), placeholders
+}
+
+// normalizePII 去掉写法差异（分隔符、大小写、+86），同一个号码换种写法仍是同一占位符
+func normalizePII(typ, v string) string {
+	switch typ {
+	case "email", "cn_id":
+		return strings.ToLower(v)
+	case "phone", "bank_card":
+		v = strings.NewReplacer(" ", "", "-", "", "+", "").Replace(v)
+		if typ == "phone" && len(v) == 13 {
+			v = strings.TrimPrefix(v, "86")
+		}
+	}
+	return v
+}
+
+// obscure 把 hits 覆盖的字符换成 *，长度不变：模型回复的 redact 与拦截事件里的原文都用它，命中位置仍然对得上
+func obscure(text string, hits []hit) string {
+	if len(hits) == 0 {
+		return text
+	}
+	rs := []rune(text)
+	for _, h := range hits {
+		for i := h.start; i < h.end; i++ {
+			if !unicode.IsSpace(rs[i]) {
+				rs[i] = '*'
+			}
+		}
+	}
+	return string(rs)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// 占位符接着同一请求里其他文本用掉的编号往下排，同一值仍复用同一占位符
+func TestMaskPIIOffsets(t *testing.T) {
+	text := "call 13800138000 or 13900139000, again 13800138000, mail a@b.com"
+	hits := detectPII(text, map[string]piiSpec{"phone": {Action: "mask"}, "email": {Action: "mask"}})
+	for _, tc := range []struct {
+		name    string
+		offsets map[string]int32
+		want    string
+	}{
+		{"no offsets", nil, "call [PHONE_1] or [PHONE_2], again [PHONE_1], mail [EMAIL_1]"},
+		{"phone offset", map[string]int32{"PHONE": 3}, "call [PHONE_4] or [PHONE_5], again [PHONE_4], mail [EMAIL_1]"},
+		{"both", map[string]int32{"PHONE": 1, "EMAIL": 2}, "call [PHONE_2] or [PHONE_3], again [PHONE_2], mail [EMAIL_3]"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, placeholders := maskPII(text, hits, tc.offsets)
+			if got != tc.want {
+				t.Fatalf("maskPII = %q, want %q", got, tc.want)
+			}
+			if len(placeholders) != len(hits) || slices.Contains(placeholders, "") {
+				t.Fatalf("placeholders = %v, want one per hit", placeholders)
+			}
+		})
+	}
+}
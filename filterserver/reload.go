@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	ruleReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "filter_rule_reloads_total",
+		Help: "Rule file reloads by result (ok, error).",
+	}, []string{"result"})
+	rulesetInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "filter_ruleset_info",
+		Help: "Always 1; the version label is the active rule set.",
+	}, []string{"version"})
+)
+
+// rules 持有当前生效的规则集；热更新时整体替换，进行中的请求继续使用旧版本。
+// load 读取最新的规则：规则文件（filter.store=file）或 MySQL 里生效的版本（filter.store=mysql）
+type rules struct {
+	load func(ctx context.Context) (*ruleset, error)
+	cur  atomic.Pointer[ruleset]
+}
+
+func newRules(ctx context.Context, load func(ctx context.Context) (*ruleset, error)) (*rules, error) {
+	rs, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r := &rules{load: load}
+	r.swap(rs)
+	return r, nil
+}
+
+func (r *rules) get() *ruleset { return r.cur.Load() }
+
+func (r *rules) swap(rs *ruleset) {
+	if old := r.cur.Swap(rs); old != nil {
+		rulesetInfo.DeleteLabelValues(old.version)
+	}
+	rulesetInfo.WithLabelValues(rs.version).Set(1)
+}
+
+// reload 重新加载规则；规则有误（或 MySQL 不可用）时记日志并保留旧规则
+func (r *rules) reload(ctx context.Context, reason string) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	rs, err := r.load(ctx)
+	if err != nil {
+		ruleReloads.WithLabelValues("error").Inc()
+		slog.Error("rule reload failed, keeping current rules", "reason", reason, "version", r.get().version, "error", err)
+		return
+	}
+	old := r.get().version
+	r.swap(rs)
+	ruleReloads.WithLabelValues("ok").Inc()
+	slog.Info("rules reloaded", "reason", reason, "from", old, "to", rs.version, "rules", len(rs.rules))
+}
+
+// watch 在规则文件变化或收到 SIGHUP 时重新加载，直到 ctx 结束（filter.store=file）。
+// 监听的是所在目录：编辑器保存、kubectl 挂载的 ConfigMap 更新都是“写新文件再改名”，直接监听文件会丢事件。
+func (r *rules) watch(ctx context.Context, path string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var (
+		events <-chan fsnotify.Event
+		errs   <-chan error
+	)
+	w, err := fsnotify.NewWatcher()
+	if err == nil {
+		defer w.Close()
+		if err = w.Add(filepath.Dir(path)); err == nil {
+			events, errs = w.Events, w.Errors
+		}
+	}
+	if err != nil {
+		slog.Warn("rule file watch unavailable, reload with SIGHUP only", "error", err)
+	}
+
+	// 一次保存常伴随多个事件，合并 200ms 内的事件只加载一次
+	var debounce <-chan time.Time
+	name := filepath.Clean(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload(ctx, "SIGHUP")
+		case ev := <-events:
+			if (filepath.Clean(ev.Name) == name && ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0) ||
+				filepath.Base(ev.Name) == "..data" { // ConfigMap 的原子切换
+				debounce = time.After(200 * time.Millisecond)
+			}
+		case err := <-errs:
+			slog.Warn("rule file watch error", "error", err)
+		case <-debounce:
+			debounce = nil
+			r.reload(ctx, "file changed")
+		}
+	}
+}
+
+// follow 跟随 MySQL 里生效的版本（filter.store=mysql），直到 ctx 结束：激活时经 Redis pub/sub 收到通知，
+// 另外每隔 interval 核对一次（Redis 断线重连期间的通知会丢），收到 SIGHUP 时无条件重新加载
+func (r *rules) follow(ctx context.Context, st *policyStore, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	sub := st.rdb.Subscribe(ctx, policyChannel)
+	defer sub.Close()
+	notes := sub.Channel()
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload(ctx, "SIGHUP")
+		case m := <-notes:
+			if m.Payload != r.get().version {
+				r.reload(ctx, "policy activated")
+			}
+		case <-tick.C:
+			v, err := st.activeVersion(ctx)
+			if err != nil {
+				slog.Warn("check active policy", "error", err)
+				continue
+			}
+			if v != "" && v != r.get().version {
+				r.reload(ctx, "policy sync")
+			}
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+
+	pb "chatgpt-demo/chatpb"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleFile 是规则文件（filter.rules_file）的格式：
+//
+//	version: 2026-10-16.1      # 可选；为空时取文件内容的 sha256 前 12 位
+//	rules:
+//	  - id: profanity-en
+//	    category: profanity     # 见 categories，默认 other
+//	    severity: medium        # low | medium（默认）| high | critical
+//	    words: [badword, foo]   # 词表，大小写、全角、形近字、leetspeak 不敏感（见 normalize.go）
+//	    match: word             # word（默认，整词）| substring（子串）
+//	    direction: both         # input（只审用户输入）| output（只审模型回复）| both（默认）
+//	    output_action: redact   # 回复里命中时：block（整条拦截）| redact（逐字换成 *）；默认取 output.action
+//	    mode: shadow            # enforce（默认）| shadow（只记日志与指标，不拦截、不遮盖）
+//	  - id: bad-regex
+//	    regex: 'b[a@]dw[o0]rd'  # 正则，大小写不敏感，在 NFKD + 去不可见字符 + 形近字折叠后的文本上匹配；需要整词时自己写 \b
+//	allow: [foobar]             # 白名单：完全落在白名单词组内的命中不算
+//	pii:                        # 用户输入里个人敏感信息的处理策略，见 piiSpec
+//	  phone: {action: mask, restore: true}
+//	output:                     # 模型回复的策略
+//	  action: block             # 规则命中时的默认处理：block（默认）| redact
+//	  pii:                      # 回复里的个人敏感信息：redact | block | off
+//	    cn_id: {action: redact}
+//	injection:                  # 用户输入的提示词注入评分，见 injectionSpec
+//	  threshold: 0.5
+type ruleFile struct {
+	Version string             `yaml:"version,omitempty"`
+	Rules   []ruleSpec         `yaml:"rules,omitempty"`
+	Allow   []string           `yaml:"allow,omitempty"`
+	PII     map[string]piiSpec `yaml:"pii,omitempty"`
+	Output  outputSpec         `yaml:"output,omitempty"`
+
+	Injection injectionSpec `yaml:"injection,omitempty"`
+}
+
+type outputSpec struct {
+	Action string             `yaml:"action,omitempty"`
+	PII    map[string]piiSpec `yaml:"pii,omitempty"`
+}
+
+type ruleSpec struct {
+	ID       string   `yaml:"id,omitempty"`
+	Category string   `yaml:"category,omitempty"`
+	Severity string   `yaml:"severity,omitempty"`
+	Words    []string `yaml:"words,omitempty"`
+	Match    string   `yaml:"match,omitempty"`
+	Regex    string   `yaml:"regex,omitempty"`
+
+	Direction    string `yaml:"direction,omitempty"`
+	OutputAction string `yaml:"output_action,omitempty"`
+	Mode         string `yaml:"mode,omitempty"`
+}
+
+// categories 是规则可用的分类，网关按分类给出本地化的拦截原因
+var categories = map[string]bool{
+	"profanity": true, "hate": true, "sexual": true, "violence": true, "self_harm": true,
+	"illegal": true, "pii": true, "injection": true, "spam": true, "other": true,
+}
+
+// severities 为严重程度及其排序，多条命中时取最严重的一条作为整体判定
+var severities = map[string]int{"low": 1, "medium": 2, "high": 3, "critical": 4}
+
+// ruleset 是编译好的一版规则，加载后只读，可以被多个请求并发使用
+type ruleset struct {
+	version string
+	rules   []ruleMeta // 规则下标 → 规则
+
+	words    *acMachine // 所有规则的词表合成一个自动机
+	patterns []wordPattern
+	regexes  []regexRule
+	allow    *acMachine
+
+	pii       map[string]piiSpec // 类型 → 策略（已校验、补全默认值）
+	outputPII map[string]piiSpec
+
+	injection *injectionScorer
+}
+
+type ruleMeta struct {
+	id, category, severity string
+	input, output          bool   // 适用的方向
+	outputAction           string // 回复里命中时：block | redact
+	shadow                 bool   // 影子模式：命中只记日志与指标
+}
+
+func (m *ruleMeta) applies(d pb.FilterDirection) bool {
+	if d == pb.FilterDirection_OUTPUT {
+		return m.output
+	}
+	return m.input
+}
+
+type wordPattern struct {
+	rule  int
+	whole bool
+}
+
+type regexRule struct {
+	rule int
+	re   *regexp.Regexp
+}
+
+// hit 是一次命中；[start, end) 为 rune 下标
+type hit struct {
+	rule       *ruleMeta
+	start, end int
+}
+
+// loadRuleset 读取并编译规则文件，任何一条规则有误都整体失败（热更新时保留旧规则）
+func loadRuleset(path string) (*ruleset, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := parseRuleset(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rs, nil
+}
+
+// parseRuleset 编译规则文件的内容（试运行的候选规则、MySQL 里的规则版本不落盘，直接走这里）
+func parseRuleset(b []byte) (*ruleset, error) {
+	f, err := decodeRuleFile(b)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := compileRules(f)
+	if err != nil {
+		return nil, err
+	}
+	if rs.version == "" {
+		sum := sha256.Sum256(b)
+		rs.version = hex.EncodeToString(sum[:6])
+	}
+	return rs, nil
+}
+
+// decodeRuleFile 解析规则文件，不认识的字段报错（拼错的键不会被悄悄忽略）
+func decodeRuleFile(b []byte) (ruleFile, error) {
+	var f ruleFile
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	err := dec.Decode(&f)
+	return f, err
+}
+
+func compileRules(f ruleFile) (*ruleset, error) {
+	rs := &ruleset{version: f.Version}
+	outAction := f.Output.Action
+	switch outAction {
+	case "":
+		outAction = "block"
+	case "block", "redact":
+	default:
+		return nil, fmt.Errorf("output: unknown action %q (want block|redact)", f.Output.Action)
+	}
+	var words [][]rune
+	seen := map[string]bool{}
+	for i, r := range f.Rules {
+		if r.ID == "" {
+			return nil, fmt.Errorf("rule #%d: missing id", i+1)
+		}
+		if seen[r.ID] {
+			return nil, fmt.Errorf("rule %s: duplicate id", r.ID)
+		}
+		seen[r.ID] = true
+		if (len(r.Words) == 0) == (r.Regex == "") {
+			return nil, fmt.Errorf("rule %s: want exactly one of words or regex", r.ID)
+		}
+		m := ruleMeta{id: r.ID, category: r.Category, severity: r.Severity}
+		if m.category == "" {
+			m.category = "other"
+		}
+		if !categories[m.category] {
+			return nil, fmt.Errorf("rule %s: unknown category %q", r.ID, r.Category)
+		}
+		if m.severity == "" {
+			m.severity = "medium"
+		}
+		if severities[m.severity] == 0 {
+			return nil, fmt.Errorf("rule %s: unknown severity %q (want low|medium|high|critical)", r.ID, r.Severity)
+		}
+		switch r.Direction {
+		case "", "both":
+			m.input, m.output = true, true
+		case "input":
+			m.input = true
+		case "output":
+			m.output = true
+		default:
+			return nil, fmt.Errorf("rule %s: unknown direction %q (want input|output|both)", r.ID, r.Direction)
+		}
+		switch m.outputAction = r.OutputAction; m.outputAction {
+		case "":
+			m.outputAction = outAction
+		case "block", "redact":
+		default:
+			return nil, fmt.Errorf("rule %s: unknown output_action %q (want block|redact)", r.ID, r.OutputAction)
+		}
+		switch r.Mode {
+		case "", "enforce":
+		case "shadow":
+			m.shadow = true
+		default:
+			return nil, fmt.Errorf("rule %s: unknown mode %q (want enforce|shadow)", r.ID, r.Mode)
+		}
+		idx := len(rs.rules)
+		rs.rules = append(rs.rules, m)
+
+		if r.Regex != "" {
+			re, err := regexp.Compile("(?i)" + r.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", r.ID, err)
+			}
+			rs.regexes = append(rs.regexes, regexRule{rule: idx, re: re})
+			continue
+		}
+		var whole bool
+		switch r.Match {
+		case "", "word":
+			whole = true
+		case "substring":
+		default:
+			return nil, fmt.Errorf("rule %s: unknown match %q (want word|substring)", r.ID, r.Match)
+		}
+		for _, w := range r.Words {
+			p := canonical(w)
+			if len(p) == 0 {
+				return nil, fmt.Errorf("rule %s: empty word", r.ID)
+			}
+			words = append(words, p)
+			rs.patterns = append(rs.patterns, wordPattern{rule: idx, whole: whole})
+		}
+	}
+	var err error
+	if rs.pii, err = compilePII("pii", f.PII, "mask"); err != nil {
+		return nil, err
+	}
+	if rs.outputPII, err = compilePII("output.pii", f.Output.PII, "redact"); err != nil {
+		return nil, err
+	}
+	if rs.injection, err = compileInjection(f.Injection); err != nil {
+		return nil, err
+	}
+	if len(rs.rules) == 0 && len(rs.pii) == 0 && len(rs.outputPII) == 0 {
+		return nil, errors.New("no rules")
+	}
+	rs.words = newAC(words)
+
+	allow := make([][]rune, 0, len(f.Allow))
+	for _, a := range f.Allow {
+		if p := canonical(a); len(p) > 0 {
+			allow = append(allow, p)
+		}
+	}
+	rs.allow = newAC(allow)
+	return rs, nil
+}
+
+// compilePII 校验一个方向的个人敏感信息策略；输入方向遮盖为 mask（占位符），输出方向为 redact（逐字换成 *）
+func compilePII(section string, m map[string]piiSpec, hide string) (map[string]piiSpec, error) {
+	out := map[string]piiSpec{}
+	for typ, p := range m {
+		if !piiTypes[typ] {
+			return nil, fmt.Errorf("%s.%s: unknown type (want phone|email|cn_id|bank_card|api_key)", section, typ)
+		}
+		if p.Action != hide && p.Action != "block" && p.Action != "off" {
+			return nil, fmt.Errorf("%s.%s: unknown action %q (want %s|block|off)", section, typ, p.Action, hide)
+		}
+		if p.Restore && hide != "mask" {
+			return nil, fmt.Errorf("%s.%s: restore only applies to masked input", section, typ)
+		}
+		if p.Severity == "" {
+			p.Severity = "high"
+		}
+		if severities[p.Severity] == 0 {
+			return nil, fmt.Errorf("%s.%s: unknown severity %q (want low|medium|high|critical)", section, typ, p.Severity)
+		}
+		out[typ] = p
+	}
+	return out, nil
+}
+
+// match 返回文本里的全部命中（已去掉落在白名单内的），位置为原文的 rune 下标。
+// base 为归一化后的文本（见 normalize / loosen）：词表与白名单在宽松文本上匹配，正则在 base 上匹配。
+func (rs *ruleset) match(base *normText) []hit {
+	loose := base.loosen()
+	t := loose.r
+	var hits []hit
+	rs.words.findAll(t, func(pat, start, end int) {
+		p := rs.patterns[pat]
+		if p.whole && !wholeWord(t, start, end) {
+			return
+		}
+		s, e := loose.span(start, end)
+		hits = append(hits, hit{rule: &rs.rules[p.rule], start: s, end: e})
+	})
+	if len(rs.regexes) > 0 {
+		s := string(base.r)
+		for _, r := range rs.regexes {
+			for _, loc := range r.re.FindAllStringIndex(s, -1) {
+				if loc[0] == loc[1] {
+					continue
+				}
+				start := utf8.RuneCountInString(s[:loc[0]])
+				from, to := base.span(start, start+utf8.RuneCountInString(s[loc[0]:loc[1]]))
+				hits = append(hits, hit{rule: &rs.rules[r.rule], start: from, end: to})
+			}
+		}
+	}
+	if len(hits) == 0 {
+		return nil
+	}
+
+	// 白名单：命中完全落在某个白名单词组里时忽略，比如屏蔽 foo 但放行 foobar
+	var allowed [][2]int
+	rs.allow.findAll(t, func(_, start, end int) {
+		s, e := loose.span(start, end)
+		allowed = append(allowed, [2]int{s, e})
+	})
+	if len(allowed) == 0 {
+		return hits
+	}
+	kept := hits[:0]
+	for _, h := range hits {
+		covered := false
+		for _, a := range allowed {
+			if a[0] <= h.start && h.end <= a[1] {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
+
+// verdict 是一次判定的中间结果，位置均为原文的 rune 下标
+type verdict struct {
+	policy    map[string]piiSpec // 本方向的个人敏感信息策略
+	blocking  []hit              // 导致拦截的命中（含 block 策略的个人敏感信息）
+	redacted  []hit              // OUTPUT：要逐字遮盖的命中
+	pii       []piiHit           // 检出的全部个人敏感信息
+	masked    []piiHit           // INPUT：要替换成占位符的个人敏感信息
+	personal  []hit              // pii 对应的命中，用于在拦截事件里遮盖原文
+	injection injectionResult    // INPUT：提示词注入评分
+
+	// 影子模式规则的命中，按生效后的处理分为拦截与遮盖；不影响判定
+	shadowBlocking, shadowRedacted []hit
+}
+
+// judge 按方向对文本做一次判定：规则命中按 direction / output_action 分为拦截与遮盖，个人敏感信息按本方向的策略处理，
+// 用户输入另做提示词注入评分（只评分，是否拦截由网关按租户策略决定）
+func (rs *ruleset) judge(text string, dir pb.FilterDirection) verdict {
+	output := dir == pb.FilterDirection_OUTPUT
+	base := normalize(text)
+	v := verdict{policy: rs.pii}
+	if output {
+		v.policy = rs.outputPII
+	} else {
+		v.injection = rs.injection.score(text, base)
+	}
+	for _, h := range rs.match(base) {
+		switch {
+		case !h.rule.applies(dir):
+		case h.rule.shadow && output && h.rule.outputAction == "redact":
+			v.shadowRedacted = append(v.shadowRedacted, h)
+		case h.rule.shadow:
+			v.shadowBlocking = append(v.shadowBlocking, h)
+		case output && h.rule.outputAction == "redact":
+			v.redacted = append(v.redacted, h)
+		default:
+			v.blocking = append(v.blocking, h)
+		}
+	}
+	v.pii = detectPII(text, v.policy)
+	v.personal = make([]hit, len(v.pii))
+	for i, h := range v.pii {
+		p := v.policy[h.typ]
+		v.personal[i] = hit{rule: &ruleMeta{id: "pii." + h.typ, category: "pii", severity: p.Severity}, start: h.start, end: h.end}
+		switch {
+		case p.Action == "block":
+			v.blocking = append(v.blocking, v.personal[i])
+		case output:
+			v.redacted = append(v.redacted, v.personal[i])
+		default:
+			v.masked = append(v.masked, h)
+		}
+	}
+	return v
+}
+
+// wholeWord 判断 [start, end) 两侧没有与之连成一个词的字母/数字。
+// 中日文本身不以空格分词，两侧是汉字/假名时不要求边界。
+func wholeWord(t []rune, start, end int) bool {
+	if start > 0 && joins(t[start-1], t[start]) {
+		return false
+	}
+	if end < len(t) && joins(t[end-1], t[end]) {
+		return false
+	}
+	return true
+}
+
+func joins(a, b rune) bool {
+	return isWordRune(a) && isWordRune(b) && !unspaced(a) && !unspaced(b)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func unspaced(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/logging"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+)
+
+// 激活新版本后在这个频道发布版本号，各副本收到后从 MySQL 加载
+const policyChannel = "filter:policy"
+
+var errNoPolicyState = status.Error(codes.FailedPrecondition, "filter_policy_state is empty; run sql/004_filter_policies.sql")
+
+// policyStore 是 MySQL 里的规则版本（filter.store=mysql）：每个版本是一份完整的规则文件，
+// filter_policy_state 记录最新版本与生效版本。还没有任何版本时，第一次改动以规则文件（file）为基础
+type policyStore struct {
+	db   *sql.DB
+	rdb  *redis.Client
+	file string
+}
+
+// 对外的版本号为 v<id>，与规则文件里的 version 区分开
+func versionName(id int64) string { return "v" + strconv.FormatInt(id, 10) }
+
+func parseVersion(v string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(v, "v"), 10, 64)
+	if err != nil || !strings.HasPrefix(v, "v") || id <= 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "version %q: want v<number>", v)
+	}
+	return id, nil
+}
+
+func nullVersion(id sql.NullInt64) string {
+	if !id.Valid {
+		return ""
+	}
+	return versionName(id.Int64)
+}
+
+// queryer 是 *sql.DB 或 *sql.Tx：事务里读要加锁的行，事务外直接读
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// state 返回最新版本与生效版本（没有时 Valid 为 false）
+func (st *policyStore) state(ctx context.Context, q queryer, lock bool) (latest, active sql.NullInt64, err error) {
+	query := "SELECT latest_id, active_id FROM filter_policy_state WHERE id=1"
+	if lock {
+		query += " FOR UPDATE"
+	}
+	err = q.QueryRowContext(ctx, query).Scan(&latest, &active)
+	if err == sql.ErrNoRows {
+		err = errNoPolicyState
+	}
+	return latest, active, err
+}
+
+// body 返回某个版本的规则文件内容；不存在时为 NotFound
+func (st *policyStore) body(ctx context.Context, q queryer, id int64) ([]byte, error) {
+	var b []byte
+	err := q.QueryRowContext(ctx, "SELECT body FROM filter_policies WHERE id=?", id).Scan(&b)
+	if err == sql.ErrNoRows {
+		return nil, status.Errorf(codes.NotFound, "policy %s not found", versionName(id))
+	}
+	return b, err
+}
+
+// load 编译某个版本，版本号用 v<id>
+func (st *policyStore) load(ctx context.Context, id int64) (*ruleset, error) {
+	b, err := st.body(ctx, st.db, id)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := parseRuleset(b)
+	if err != nil {
+		return nil, fmt.Errorf("policy %s: %w", versionName(id), err)
+	}
+	rs.version = versionName(id)
+	return rs, nil
+}
+
+// loadActive 加载生效的版本；还没有激活过任何版本时用规则文件
+func (st *policyStore) loadActive(ctx context.Context) (*ruleset, error) {
+	_, active, err := st.state(ctx, st.db, false)
+	if err != nil {
+		return nil, err
+	}
+	if !active.Valid {
+		return loadRuleset(st.file)
+	}
+	return st.load(ctx, active.Int64)
+}
+
+// activeVersion 返回生效的版本号（还没有时为空），用于定期核对是否错过了通知
+func (st *policyStore) activeVersion(ctx context.Context) (string, error) {
+	_, active, err := st.state(ctx, st.db, false)
+	return nullVersion(active), err
+}
+
+// edit 是对规则列表的一次改动，返回改动前后的规则（新增时 before 为 nil，删除时 after 为 nil）
+type edit func(f *ruleFile) (before, after *ruleSpec, err error)
+
+// change 在最新版本上做一次改动：锁住 filter_policy_state 串行生成新版本，新版本编译通过才保存，
+// 同一事务里写审计日志。只生成版本，不激活
+func (st *policyStore) change(ctx context.Context, action, target string, in changeRequest, fn edit) (*pb.FilterPolicy, error) {
+	if in.actor == "" {
+		return nil, status.Error(codes.InvalidArgument, "actor is required")
+	}
+	tx, err := st.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	latest, _, err := st.state(ctx, tx, true)
+	if err != nil {
+		return nil, err
+	}
+	if in.base != "" && in.base != nullVersion(latest) {
+		return nil, status.Errorf(codes.FailedPrecondition, "base_version %s is stale, latest is %s", in.base, nullVersion(latest))
+	}
+	var b []byte
+	if latest.Valid {
+		b, err = st.body(ctx, tx, latest.Int64)
+	} else {
+		b, err = os.ReadFile(st.file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	f, err := decodeRuleFile(b)
+	if err != nil {
+		return nil, fmt.Errorf("policy %s: %w", baseName(latest, st.file), err)
+	}
+	before, after, err := fn(&f)
+	if err != nil {
+		return nil, err
+	}
+	f.Version = "" // 版本号由数据库分配
+	if _, err := compileRules(f); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	body, err := yaml.Marshal(&f)
+	if err != nil {
+		return nil, err
+	}
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO filter_policies(parent_id, body, rule_count, created_by, comment) VALUES(?,?,?,?,?)",
+		latest, body, len(f.Rules), in.actor, in.comment)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE filter_policy_state SET latest_id=? WHERE id=1", id); err != nil {
+		return nil, err
+	}
+	if err := audit(ctx, tx, in, action, target, id, ruleJSON(before), ruleJSON(after)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &pb.FilterPolicy{
+		Version:   versionName(id),
+		Parent:    nullVersion(latest),
+		CreatedBy: in.actor,
+		CreatedAt: time.Now().Unix(),
+		Comment:   in.comment,
+		RuleCount: int32(len(f.Rules)),
+	}, nil
+}
+
+// baseName 用于错误信息：基础版本的版本号，还没有版本时为规则文件路径
+func baseName(latest sql.NullInt64, file string) string {
+	if latest.Valid {
+		return versionName(latest.Int64)
+	}
+	return file
+}
+
+// changeRequest 是改动的操作人与说明，写入版本与审计日志
+type changeRequest struct {
+	actor, actorKey, comment, base string
+}
+
+// audit 写一条审计日志；before/after 为空时存 NULL
+func audit(ctx context.Context, tx *sql.Tx, in changeRequest, action, target string, policyID int64, before, after string) error {
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO filter_audit(actor, actor_key, action, target, policy_id, before_json, after_json, comment) VALUES(?,?,?,?,?,?,?,?)",
+		in.actor, in.actorKey, action, target, policyID, nullString(before), nullString(after), in.comment)
+	return err
+}
+
+func nullString(s string) sql.NullString { return sql.NullString{String: s, Valid: s != ""} }
+
+// ruleJSON 是审计日志里的规则（FilterRule 的 JSON）；nil 为空串
+func ruleJSON(r *ruleSpec) string {
+	if r == nil {
+		return ""
+	}
+	b, err := json.Marshal(ruleToPB(r)) // 字段名与网关接口一致（snake_case）
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// activate 切换生效版本：新版本必须仍能编译（规则格式随代码升级可能变化），成功后发布通知
+func (st *policyStore) activate(ctx context.Context, version string, in changeRequest) (*pb.FilterPolicy, error) {
+	if in.actor == "" {
+		return nil, status.Error(codes.InvalidArgument, "actor is required")
+	}
+	id, err := parseVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := st.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, active, err := st.state(ctx, tx, true)
+	if err != nil {
+		return nil, err
+	}
+	b, err := st.body(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseRuleset(b); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "policy %s does not compile: %v", version, err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE filter_policy_state SET active_id=?, activated_by=?, activated_at=CURRENT_TIMESTAMP WHERE id=1", id, in.actor); err != nil {
+		return nil, err
+	}
+	if err := audit(ctx, tx, in, "policy.activate", version, id, nullVersion(active), version); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// 通知失败不影响结果：各副本定期核对生效版本（filter.sync_interval），最迟那时切换
+	if err := st.rdb.Publish(context.WithoutCancel(ctx), policyChannel, version).Err(); err != nil {
+		logging.FromContext(ctx).Warn("publish policy activation", "version", version, "error", err)
+	}
+	p, err := scanPolicy(st.db.QueryRowContext(ctx, "SELECT "+policyCols+" FROM filter_policies WHERE id=?", id))
+	if err != nil {
+		return nil, err
+	}
+	p.Active = true
+	return p, nil
+}
+
+const policyCols = "id, parent_id, created_by, comment, rule_count, UNIX_TIMESTAMP(created_at)"
+
+type scanner interface{ Scan(dest ...any) error }
+
+func scanPolicy(row scanner) (*pb.FilterPolicy, error) {
+	var id int64
+	var parent sql.NullInt64
+	p := &pb.FilterPolicy{}
+	if err := row.Scan(&id, &parent, &p.CreatedBy, &p.Comment, &p.RuleCount, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	p.Version, p.Parent = versionName(id), nullVersion(parent)
+	return p, nil
+}
+
+func ruleToPB(r *ruleSpec) *pb.FilterRule {
+	return &pb.FilterRule{
+		Id: r.ID, Category: r.Category, Severity: r.Severity, Words: r.Words, Match: r.Match, Regex: r.Regex,
+		Direction: r.Direction, OutputAction: r.OutputAction, Mode: r.Mode,
+	}
+}
+
+func ruleFromPB(r *pb.FilterRule) ruleSpec {
+	return ruleSpec{
+		ID: r.GetId(), Category: r.GetCategory(), Severity: r.GetSeverity(), Words: r.GetWords(), Match: r.GetMatch(),
+		Regex: r.GetRegex(), Direction: r.GetDirection(), OutputAction: r.GetOutputAction(), Mode: r.GetMode(),
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	pb "chatgpt-demo/chatpb"
+
+	"github.com/gin-gonic/gin"
+)
+
+type issueKeyReq struct {
+	UserID   string   `json:"user_id"`
+	TenantID string   `json:"tenant_id"`
+	Scopes   []string `json:"scopes"`
+	Name     string   `json:"name"`
+}
+
+// adminTenant 返回管理员可管理的租户：绑定租户的管理员只能管理本租户的 key，全局管理员为空
+func adminTenant(c *gin.Context) string {
+	return apiKey(c).GetTenantId()
+}
+
+// POST /admin/keys {"user_id":"u1","scopes":["chat","history:read"],"name":"..."}：签发 key，明文只返回这一次
+func (p *pipeline) issueKey(c *gin.Context) {
+	var req issueKeyReq
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+		return
+	}
+	if t := adminTenant(c); t != "" {
+		if req.TenantID != "" && req.TenantID != t {
+			c.JSON(http.StatusForbidden, gin.H{"error": "tenant_id does not match api key"})
+			return
+		}
+		req.TenantID = t
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.auth.IssueKey(ctx, &pb.IssueKeyRequest{
+		UserId: req.UserID, TenantId: req.TenantID, Scopes: req.Scopes, Name: req.Name,
+	})
+	if err != nil {
+		writeRPCError(c, "auth failed", err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// GET /admin/keys?user_id=u1&tenant_id=t1：列出 key（不含明文与哈希）
+func (p *pipeline) listKeys(c *gin.Context) {
+	tenant := c.Query("tenant_id")
+	if t := adminTenant(c); t != "" {
+		tenant = t
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.auth.ListKeys(ctx, &pb.ListKeysRequest{UserId: c.Query("user_id"), TenantId: tenant})
+	if err != nil {
+		writeRPCError(c, "auth failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, resp.GetKeys())
+}
+
+// POST /admin/keys/:id/rotate：换新明文，旧明文立即失效
+func (p *pipeline) rotateKey(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.auth.RotateKey(ctx, &pb.RotateKeyRequest{Id: c.Param("id"), TenantId: adminTenant(c)})
+	if err != nil {
+		writeRPCError(c, "auth failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DELETE /admin/keys/:id：吊销
+func (p *pipeline) revokeKey(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	if _, err := p.auth.RevokeKey(ctx, &pb.RevokeKeyRequest{Id: c.Param("id"), TenantId: adminTenant(c)}); err != nil {
+		writeRPCError(c, "auth failed", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	pb "chatgpt-demo/chatpb"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 权限范围（与 authserver 一致）
+const (
+	scopeChat        = "chat"
+	scopeHistoryRead = "history:read"
+	scopeAdmin       = "admin"
+)
+
+// gin.Context 里保存已校验 key 与解析后用户身份的键名
+const (
+	ctxAPIKey = "api_key"
+	ctxUserID = "user_id"
+)
+
+// bearer 从 Authorization: Bearer <key> 或 X-API-Key 里取出 key
+func bearer(c *gin.Context) string {
+	if h := c.GetHeader("Authorization"); h != "" {
+		if k, ok := strings.CutPrefix(h, "Bearer "); ok {
+			return strings.TrimSpace(k)
+		}
+	}
+	return c.GetHeader("X-API-Key")
+}
+
+// authenticate 中间件：校验 API Key（authserver 带 Redis 缓存），通过后把 key 放进 gin.Context
+func (p *pipeline) authenticate(c *gin.Context) {
+	key := bearer(c)
+	if key == "" {
+		c.Header("WWW-Authenticate", `Bearer realm="chatgpt-demo"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing_api_key"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.RPC.Duration)
+	defer cancel()
+	k, err := p.auth.Authenticate(ctx, &pb.AuthenticateRequest{Key: key})
+	if status.Code(err) == codes.Unauthenticated {
+		c.Header("WWW-Authenticate", `Bearer realm="chatgpt-demo", error="invalid_token"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid_api_key"})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "auth failed", "detail": status.Convert(err).Message()})
+		return
+	}
+	c.Set(ctxAPIKey, k)
+	c.Next()
+}
+
+// requireScope 要求当前 key 拥有指定权限，否则 403
+func requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(apiKey(c).GetScopes(), scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden", "required_scope": scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+func apiKey(c *gin.Context) *pb.ApiKey {
+	k, _ := c.MustGet(ctxAPIKey).(*pb.ApiKey)
+	return k
+}
+
+// 租户 key 代终端用户调用时，请求里的 user_id 只能由这些字符组成（不含租户分隔符 "/"）；
+// 拼上租户前缀后不能超过历史与会话表 user_id 列的宽度
+var claimedUserRe = regexp.MustCompile(`^[A-Za-z0-9._@-]+$`)
+
+const maxUserID = 64
+
+// userID 由 API Key 决定本次请求的用户身份，不信任请求体/查询参数：
+//
+//	用户 key（无租户）       → key.user_id；请求里的 user_id 必须为空或一致
+//	租户内的用户 key         → tenant/user_id
+//	租户 key（无 user_id）   → tenant/<请求里的 user_id>，由租户后端代其终端用户调用
+//
+// 失败时已经写好 HTTP 响应；成功时身份也记进 gin.Context，供访问日志使用。
+func userID(c *gin.Context, claimed string) (string, bool) {
+	k := apiKey(c)
+	var id string
+	switch {
+	case k.GetUserId() != "":
+		if claimed != "" && claimed != k.GetUserId() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "user_id does not match api key"})
+			return "", false
+		}
+		id = k.GetUserId()
+		if k.GetTenantId() != "" {
+			id = k.GetTenantId() + "/" + id
+		}
+	case claimed == "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user_id (required with a tenant key)"})
+		return "", false
+	default:
+		id = k.GetTenantId() + "/" + claimed
+		if !claimedUserRe.MatchString(claimed) || len(id) > maxUserID {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "bad user_id", "detail": "user_id may only contain letters, digits and . _ @ - and must fit 64 bytes with the tenant prefix",
+			})
+			return "", false
+		}
+	}
+	c.Set(ctxUserID, id)
+	return id, true
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	pb "chatgpt-demo/chatpb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// user_id 只在租户 key 下使用（指定终端用户）
+type conversationReq struct {
+	UserID string `json:"user_id"`
+	Title  string `json:"title"`
+}
+
+// GET /conversations：按最近活跃倒序列出会话
+func (p *pipeline) listConversations(c *gin.Context) {
+	user, ok := userID(c, c.Query("user_id"))
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.history.ListConversations(ctx, &pb.ListConversationsRequest{UserId: user})
+	if err != nil {
+		writeRPCError(c, "history failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, resp.GetConversations())
+}
+
+// POST /conversations {"title":"..."}：新建会话（"新对话"）
+func (p *pipeline) createConversation(c *gin.Context) {
+	var req conversationReq
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+		return
+	}
+	user, ok := userID(c, req.UserID)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	conv, err := p.history.CreateConversation(ctx, &pb.CreateConversationRequest{UserId: user, Title: req.Title})
+	if err != nil {
+		writeRPCError(c, "history failed", err)
+		return
+	}
+	c.JSON(http.StatusCreated, conv)
+}
+
+// PATCH /conversations/:id {"title":"..."}：重命名
+func (p *pipeline) renameConversation(c *gin.Context) {
+	var req conversationReq
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+		return
+	}
+	user, ok := userID(c, req.UserID)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	conv, err := p.history.RenameConversation(ctx, &pb.RenameConversationRequest{
+		UserId: user, ConversationId: c.Param("id"), Title: req.Title,
+	})
+	if err != nil {
+		writeRPCError(c, "history failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, conv)
+}
+
+// DELETE /conversations/:id：删除会话及其消息
+func (p *pipeline) deleteConversation(c *gin.Context) {
+	user, ok := userID(c, c.Query("user_id"))
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	if _, err := p.history.DeleteConversation(ctx, &pb.DeleteConversationRequest{
+		UserId: user, ConversationId: c.Param("id"),
+	}); err != nil {
+		writeRPCError(c, "history failed", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /conversations/:id/messages?limit=20&cursor=...&direction=older：会话内的消息
+func (p *pipeline) conversationMessages(c *gin.Context) {
+	user, ok := userID(c, c.Query("user_id"))
+	if !ok {
+		return
+	}
+	p.listPage(c, user, c.Param("id"))
+}
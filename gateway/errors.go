@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// llmError 描述一次 LLM 调用失败对应的 HTTP 响应
+type llmError struct {
+	status     int
+	body       gin.H
+	retryAfter time.Duration
+}
+
+// classifyLLMError 按 llmserver 返回的 gRPC 状态码 + errdetails 映射 HTTP 状态码：
+//
+//	ResourceExhausted + QuotaFailure → 402 insufficient_quota
+//	ResourceExhausted               → 429 rate_limited（带 Retry-After）
+//	Unavailable                     → 503 llm_unavailable（带 Retry-After）
+//	DeadlineExceeded                → 504 llm_timeout
+//	InvalidArgument                 → 400 bad request
+//	FailedPrecondition              → 502 llm_misconfigured
+//	其他                            → 500 llm failed
+func classifyLLMError(err error) llmError {
+	st := status.Convert(err)
+
+	var retryAfter time.Duration
+	quota := false
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.RetryInfo:
+			retryAfter = d.GetRetryDelay().AsDuration()
+		case *errdetails.QuotaFailure:
+			quota = true
+		}
+	}
+
+	switch st.Code() {
+	case codes.ResourceExhausted:
+		if quota {
+			// 额度不足（需要充值或开通计费）
+			return llmError{status: http.StatusPaymentRequired, body: gin.H{
+				"error":  "insufficient_quota",
+				"detail": "OpenAI 项目无可用额度：请在 Billing 中添加支付方式或购买 credits 后再试",
+			}}
+		}
+		// 速率限制（429）
+		return llmError{status: http.StatusTooManyRequests, retryAfter: retryAfter, body: gin.H{
+			"error":  "rate_limited",
+			"detail": "触发速率限制，稍后重试或降低并发/频率",
+		}}
+	case codes.Unavailable:
+		return llmError{status: http.StatusServiceUnavailable, retryAfter: retryAfter, body: gin.H{
+			"error": "llm_unavailable", "detail": st.Message(),
+		}}
+	case codes.DeadlineExceeded:
+		return llmError{status: http.StatusGatewayTimeout, body: gin.H{
+			"error": "llm_timeout", "detail": st.Message(),
+		}}
+	case codes.InvalidArgument:
+		// 模型不在白名单等请求参数问题
+		return llmError{status: http.StatusBadRequest, body: gin.H{
+			"error": "bad request", "detail": st.Message(),
+		}}
+	case codes.FailedPrecondition:
+		return llmError{status: http.StatusBadGateway, body: gin.H{
+			"error": "llm_misconfigured", "detail": st.Message(),
+		}}
+	}
+	return llmError{status: http.StatusInternalServerError, body: gin.H{
+		"error": "llm failed", "detail": st.Message(),
+	}}
+}
+
+// writeLLMError 把 LLM 调用错误写成 HTTP 响应（含 Retry-After）
+func writeLLMError(c *gin.Context, err error) {
+	e := classifyLLMError(err)
+	llmErrors.WithLabelValues(e.body["error"].(string)).Inc()
+	if e.retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(e.retryAfter.Seconds()))))
+		e.body["retry_after"] = e.retryAfter.Seconds()
+	}
+	c.JSON(e.status, e.body)
+}
+
+// writeQuotaExceeded 写 tokenserver 配额拒绝的 429：window 为拒绝的配额窗口（如 tokens/minute），
+// Retry-After 为距该窗口重置的秒数
+func writeQuotaExceeded(c *gin.Context, tr *pb.ReserveReply) {
+	body := gin.H{"error": "quota exceeded", "remaining": tr.GetRemaining()}
+	if w := tr.GetWindow(); w != "" {
+		body["window"] = w
+	}
+	if reset := tr.GetResetAt(); reset > 0 {
+		retry := max(1, reset-time.Now().Unix())
+		c.Header("Retry-After", strconv.FormatInt(retry, 10))
+		body["reset_at"] = reset
+		body["retry_after"] = retry
+	}
+	c.JSON(http.StatusTooManyRequests, body)
+}
+
+// writeRPCError 把内部 gRPC 服务（history、auth 等）的错误映射成 HTTP：
+// NotFound→404，InvalidArgument→400，Unauthenticated→401，PermissionDenied→403，
+// AlreadyExists / FailedPrecondition→409，其余 500
+func writeRPCError(c *gin.Context, what string, err error) {
+	st := status.Convert(err)
+	code := http.StatusInternalServerError
+	switch st.Code() {
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.InvalidArgument:
+		code = http.StatusBadRequest
+	case codes.Unauthenticated:
+		code = http.StatusUnauthorized
+	case codes.PermissionDenied:
+		code = http.StatusForbidden
+	case codes.AlreadyExists, codes.FailedPrecondition:
+		code = http.StatusConflict
+	}
+	c.JSON(code, gin.H{"error": what, "detail": st.Message()})
+}
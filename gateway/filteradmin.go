@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	pb "chatgpt-demo/chatpb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireGlobalAdmin 只放行全局管理员：过滤规则对所有租户生效，绑定租户的管理员不能查看、修改或试运行
+func requireGlobalAdmin(c *gin.Context) {
+	if adminTenant(c) != "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "filter policies are global; tenant admins cannot manage them"})
+		return
+	}
+	c.Next()
+}
+
+type putRuleReq struct {
+	Rule        *pb.FilterRule `json:"rule"`
+	Comment     string         `json:"comment"`
+	BaseVersion string         `json:"base_version"` // 非空时要求它仍是最新版本，否则 409
+}
+
+// GET /admin/filter/policies?limit=20：规则版本，按创建时间倒序；
+// 生效版本与最新版本放在 X-Active-Policy / X-Latest-Policy 响应头（还没有版本时为空）
+func (p *pipeline) listPolicies(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.filterAdmin.ListPolicies(ctx, &pb.ListPoliciesRequest{Limit: int32(limit)})
+	if err != nil {
+		writeRPCError(c, "filter failed", err)
+		return
+	}
+	c.Header("X-Active-Policy", resp.GetActive())
+	c.Header("X-Latest-Policy", resp.GetLatest())
+	policies := resp.GetPolicies()
+	if policies == nil {
+		policies = []*pb.FilterPolicy{}
+	}
+	c.JSON(http.StatusOK, policies)
+}
+
+// GET /admin/filter/rules?version=v12：某个版本的规则（默认最新版本），版本号放在 X-Policy-Version 响应头
+func (p *pipeline) listRules(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.filterAdmin.ListRules(ctx, &pb.ListRulesRequest{Version: c.Query("version")})
+	if err != nil {
+		writeRPCError(c, "filter failed", err)
+		return
+	}
+	c.Header("X-Policy-Version", resp.GetVersion())
+	rules := resp.GetRules()
+	if rules == nil {
+		rules = []*pb.FilterRule{}
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// POST /admin/filter/rules {"rule":{...},"comment":"..."}：新增规则，生成新版本（201），激活后才生效
+func (p *pipeline) createRule(c *gin.Context) {
+	var req putRuleReq
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.filterAdmin.CreateRule(ctx, p.putRule(c, req))
+	if err != nil {
+		writeRPCError(c, "filter failed", err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// PUT /admin/filter/rules/:id {"rule":{...},"comment":"..."}：整条替换规则，生成新版本
+func (p *pipeline) updateRule(c *gin.Context) {
+	var req putRuleReq
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+		return
+	}
+	if req.Rule == nil {
+		req.Rule = &pb.FilterRule{}
+	}
+	if req.Rule.Id != "" && req.Rule.Id != c.Param("id") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rule.id does not match the path"})
+		return
+	}
+	req.Rule.Id = c.Param("id")
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.filterAdmin.UpdateRule(ctx, p.putRule(c, req))
+	if err != nil {
+		writeRPCError(c, "filter failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DELETE /admin/filter/rules/:id?comment=...&base_version=v12：删除规则，生成新版本
+func (p *pipeline) deleteRule(c *gin.Context) {
+	k := apiKey(c)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.filterAdmin.DeleteRule(ctx, &pb.DeleteRuleRequest{
+		Id: c.Param("id"), Actor: actor(k), ActorKey: k.GetId(), Comment: c.Query("comment"), BaseVersion: c.Query("base_version"),
+	})
+	if err != nil {
+		writeRPCError(c, "filter failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// POST /admin/filter/policies/:version/activate {"comment":"..."}：切换生效版本（也用于回滚），各 filterserver 副本随即切换
+func (p *pipeline) activatePolicy(c *gin.Context) {
+	var req struct {
+		Comment string `json:"comment"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+			return
+		}
+	}
+	k := apiKey(c)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.filterAdmin.ActivatePolicy(ctx, &pb.ActivatePolicyRequest{
+		Version: c.Param("version"), Actor: actor(k), ActorKey: k.GetId(), Comment: req.Comment,
+	})
+	if err != nil {
+		writeRPCError(c, "filter failed", err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GET /admin/filter/audit?target=profanity-en&limit=50&cursor=...：规则改动与激活记录，按时间倒序；
+// 下一页游标放在 X-Next-Cursor 响应头
+func (p *pipeline) listFilterAudit(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.filterAdmin.ListAudit(ctx, &pb.ListAuditRequest{
+		Limit: int32(limit), Cursor: c.Query("cursor"), Target: c.Query("target"),
+	})
+	if err != nil {
+		writeRPCError(c, "filter failed", err)
+		return
+	}
+	if nc := resp.GetNextCursor(); nc != "" {
+		c.Header("X-Next-Cursor", nc)
+	}
+	entries := resp.GetEntries()
+	if entries == nil {
+		entries = []*pb.FilterAuditEntry{}
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+func (p *pipeline) putRule(c *gin.Context, req putRuleReq) *pb.PutRuleRequest {
+	k := apiKey(c)
+	return &pb.PutRuleRequest{
+		Rule: req.Rule, Actor: actor(k), ActorKey: k.GetId(), Comment: req.Comment, BaseVersion: req.BaseVersion,
+	}
+}
+
+// actor 是审计日志里的操作人：管理员 key 的 user_id，没有绑定用户时用 key id
+func actor(k *pb.ApiKey) string {
+	if k.GetUserId() != "" {
+		return k.GetUserId()
+	}
+	return "key:" + k.GetId()
+}
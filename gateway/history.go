@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	pb "chatgpt-demo/chatpb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /history?limit=20&cursor=...&direction=older：用户维度的消息
+func (p *pipeline) listHistory(c *gin.Context) {
+	user, ok := userID(c, c.Query("user_id"))
+	if !ok {
+		return
+	}
+	p.listPage(c, user, "")
+}
+
+// listPage 按 limit / cursor / direction 查询参数取一页消息。
+// 响应体仍是消息数组；下一页游标放在 X-Next-Cursor 响应头（没有更多时不返回）。
+func (p *pipeline) listPage(c *gin.Context, user, conv string) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	dir := pb.ListDirection_OLDER
+	switch c.Query("direction") {
+	case "", "older":
+	case "newer":
+		dir = pb.ListDirection_NEWER
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "direction must be older or newer"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.history.List(ctx, &pb.ListRequest{
+		UserId:         user,
+		ConversationId: conv,
+		Limit:          int32(limit),
+		Cursor:         c.Query("cursor"),
+		Direction:      dir,
+	})
+	if err != nil {
+		writeRPCError(c, "history failed", err)
+		return
+	}
+	if nc := resp.GetNextCursor(); nc != "" {
+		c.Header("X-Next-Cursor", nc)
+	}
+	c.JSON(http.StatusOK, resp.GetItems())
+}
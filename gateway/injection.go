@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/config"
+	"chatgpt-demo/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var injectionSuspected = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gateway_injection_total",
+	Help: "Requests whose input was suspected of prompt injection, by the action taken (block, warn, tag).",
+}, []string{"action"})
+
+// 疑似提示词注入时附带的警告（warn 策略）
+var injectionWarningMessages = map[string]string{
+	"zh": "内容疑似试图绕过系统指令，已记录。",
+	"en": "Your message looks like an attempt to override system instructions and has been logged.",
+}
+
+// injectionPolicy 是疑似提示词注入的处理策略：租户 → 动作，未列出的租户与个人 key 用 def
+type injectionPolicy struct {
+	def     string
+	tenants map[string]string
+}
+
+// newInjectionPolicy 解析 gateway.moderation.injection（tenants 形如 "acme:block,beta:tag"）
+func newInjectionPolicy(cfg config.Injection) (*injectionPolicy, error) {
+	ip := &injectionPolicy{def: cfg.Action, tenants: map[string]string{}}
+	for _, part := range strings.Split(cfg.Tenants, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		tenant, action, ok := strings.Cut(part, ":")
+		if !ok || tenant == "" {
+			return nil, fmt.Errorf("INJECTION_TENANT_ACTIONS: %q: want tenant:action", part)
+		}
+		switch action {
+		case "block", "warn", "tag", "off":
+		default:
+			return nil, fmt.Errorf("INJECTION_TENANT_ACTIONS: %q: unknown action (want block|warn|tag|off)", part)
+		}
+		ip.tenants[tenant] = action
+	}
+	return ip, nil
+}
+
+func (ip *injectionPolicy) action(tenant string) string {
+	if a, ok := ip.tenants[tenant]; ok {
+		return a
+	}
+	return ip.def
+}
+
+// ctxInjectionWarning 是 gin.Context 里 warn 策略留下的警告，由 injectionWarnings 取出放进响应
+const ctxInjectionWarning = "injection_warning"
+
+// checkInjection 按 API Key 所属租户的策略处理 filterserver 判为疑似提示词注入的输入：text 与请求体显式给出的
+// history 各条都算（hrs 为 history 的过滤结果，顺序一致），取评分最高的一条处理。
+// 除 off 外都记日志、指标与 trace 属性（tag 到此为止）；warn 在响应里附带警告；
+// block 返回 400，并像 Filter 的拦截一样记一条拦截事件供人工复核。
+// 命中出自 history 时响应里的 history_index 指出是哪一条，matches 的位置相对这一条。
+// 返回 false 时已经写好 HTTP 响应，调用方直接 return 即可。
+func (p *pipeline) checkInjection(c *gin.Context, req chatReq, fr *pb.FilterReply, hrs []*pb.FilterReply) bool {
+	src, text := -1, req.Text
+	for i, hr := range hrs {
+		if hr.GetInjectionSuspected() && (!fr.GetInjectionSuspected() || hr.GetInjectionScore() > fr.GetInjectionScore()) {
+			src, text, fr = i, req.History[i].Text, hr
+		}
+	}
+	if !fr.GetInjectionSuspected() {
+		return true
+	}
+	tenant := apiKey(c).GetTenantId()
+	action := p.injection.action(tenant)
+	if action == "off" {
+		return true
+	}
+	injectionSuspected.WithLabelValues(action).Inc()
+	signals := make([]string, len(fr.GetInjectionSignals()))
+	for i, s := range fr.GetInjectionSignals() {
+		signals[i] = s.GetSignal()
+	}
+	ctx := c.Request.Context()
+	logging.FromContext(ctx).Warn("prompt injection suspected", "action", action, "tenant", tenant, "history_index", src,
+		"score", fr.GetInjectionScore(), "signals", signals, "ruleset_version", fr.GetRulesetVersion())
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Float64("chat.injection.score", float64(fr.GetInjectionScore())),
+		attribute.String("chat.injection.action", action),
+		attribute.StringSlice("chat.injection.signals", signals),
+	)
+
+	switch action {
+	case "block":
+		blocked := &pb.FilterReply{
+			RulesetVersion: fr.GetRulesetVersion(),
+			Category:       "injection",
+			Severity:       "high",
+			Matches:        signalMatches(fr),
+		}
+		p.recordBlocked(ctx, req.UserID, blocked, text)
+		reason := blockReason(c, blocked, pb.FilterDirection_INPUT)
+		if src >= 0 {
+			reason["history_index"] = src
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":           "text blocked by filter",
+			"ruleset_version": blocked.GetRulesetVersion(),
+			"reason":          reason,
+		})
+		return false
+	case "warn":
+		loc := locale(c.GetHeader("Accept-Language"))
+		w := gin.H{
+			"code":    "injection_suspected",
+			"message": injectionWarningMessages[loc],
+			"locale":  loc,
+			"score":   fr.GetInjectionScore(),
+			"matches": spans(signalMatches(fr)),
+		}
+		if src >= 0 {
+			w["history_index"] = src
+		}
+		c.Set(ctxInjectionWarning, w)
+	}
+	return true
+}
+
+// recordBlocked 把网关按策略拦下的输入补记为拦截事件（filterserver 遮盖其中的个人敏感信息）；
+// 记录失败只记日志，不影响本次响应。客户端断开也要留下记录，不跟随请求取消
+func (p *pipeline) recordBlocked(ctx context.Context, user string, fr *pb.FilterReply, text string) {
+	rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.cfg.Timeouts.RPC.Duration)
+	defer cancel()
+	_, err := p.filter.RecordBlocked(rctx, &pb.BlockedEvent{
+		UserId:         user,
+		RulesetVersion: fr.GetRulesetVersion(),
+		Category:       fr.GetCategory(),
+		Severity:       fr.GetSeverity(),
+		Text:           text,
+		Matches:        fr.GetMatches(),
+		Direction:      pb.FilterDirection_INPUT,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("record blocked event", "error", err)
+	}
+}
+
+// signalMatches 把触发的信号转成命中（rule_id 为 injection.<信号>），与规则命中同样给出原文位置
+func signalMatches(fr *pb.FilterReply) []*pb.FilterMatch {
+	out := make([]*pb.FilterMatch, len(fr.GetInjectionSignals()))
+	for i, s := range fr.GetInjectionSignals() {
+		out[i] = &pb.FilterMatch{RuleId: "injection." + s.GetSignal(), Category: "injection", Severity: "high", Start: s.GetStart(), End: s.GetEnd()}
+	}
+	return out
+}
+
+// injectionWarnings 返回响应里的 warnings（没有时为 nil）
+func injectionWarnings(c *gin.Context) []gin.H {
+	if w, ok := c.Get(ctxInjectionWarning); ok {
+		return []gin.H{w.(gin.H)}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"chatgpt-demo/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accessLog 为每个请求确定 request_id（沿用合法的 X-Request-ID，否则新生成），写回响应头并放进请求 ctx，
+// 经 gRPC metadata 透传到后端服务；请求结束后记一行 JSON 访问日志。
+// 挂在 otelgin 之后，日志里才带得上 trace_id。
+func accessLog(c *gin.Context) {
+	start := time.Now()
+	id := c.GetHeader(logging.HeaderRequestID)
+	if !logging.ValidRequestID(id) {
+		id = logging.NewRequestID()
+	}
+	c.Header(logging.HeaderRequestID, id)
+	ctx := logging.WithRequestID(c.Request.Context(), id)
+	c.Request = c.Request.WithContext(ctx)
+
+	c.Next()
+
+	status := c.Writer.Status()
+	level := slog.LevelInfo
+	switch {
+	case status >= 500:
+		level = slog.LevelError
+	case status >= 400:
+		level = slog.LevelWarn
+	}
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	attrs := []slog.Attr{
+		slog.String("method", c.Request.Method),
+		slog.String("route", route),
+		slog.Int("status", status),
+		slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		slog.String("client_ip", c.ClientIP()),
+	}
+	if user := c.GetString(ctxUserID); user != "" {
+		attrs = append(attrs, slog.String("user_id", user))
+	}
+	if len(c.Errors) > 0 {
+		attrs = append(attrs, slog.String("error", c.Errors.String()))
+	}
+	logging.FromContext(ctx).LogAttrs(ctx, level, "http", attrs...)
+}
@@ -2,21 +2,33 @@ package main
 
 import (
 	"context"
+	"log"
+	"log/slog"
 	"net/http"
-	"strings"
-	"time"
 
 	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/config"
+	"chatgpt-demo/logging"
+	"chatgpt-demo/metrics"
+	"chatgpt-demo/tracing"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/time/rate"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
 // 建立到 gRPC 服务的长连接（网关启动时创建一次）
 func mustDial(addr string) *grpc.ClientConn {
-	cc, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	cc, err := grpc.Dial(addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()), // 通过 metadata 透传 trace 上下文
+		// 记录各阶段耗时；request_id 同样经 metadata 透传
+		grpc.WithChainUnaryInterceptor(metrics.UnaryClientInterceptor, logging.UnaryClientInterceptor),
+		grpc.WithChainStreamInterceptor(metrics.StreamClientInterceptor, logging.StreamClientInterceptor),
+	)
 	if err != nil {
 		panic(err)
 	}
@@ -24,175 +36,191 @@ func mustDial(addr string) *grpc.ClientConn {
 }
 
 func main() {
+	logging.Init("gateway")
+	cfg := config.MustLoad("gateway")
+	gw := cfg.Gateway
+	shutdown, err := tracing.Init(context.Background(), "gateway")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
 	// 连接各后端 gRPC 服务
-	tokenConn := mustDial("localhost:50051")
+	tokenConn := mustDial(gw.Backends.Token)
 	defer tokenConn.Close()
-	filterConn := mustDial("localhost:50052")
+	filterConn := mustDial(gw.Backends.Filter)
 	defer filterConn.Close()
-	historyConn := mustDial("localhost:50054")
+	historyConn := mustDial(gw.Backends.History)
 	defer historyConn.Close()
-	llmConn := mustDial("localhost:50055")
+	llmConn := mustDial(gw.Backends.LLM)
 	defer llmConn.Close()
+	authConn := mustDial(gw.Backends.Auth)
+	defer authConn.Close()
+
+	// 分布式限流（Redis 令牌桶，多实例共享）
+	rdb := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr})
+	defer rdb.Close()
+	metrics.RedisPool(rdb)
+	tracing.InstrumentRedis(rdb)
+	limiter, err := newRateLimiter(rdb, gw.RateLimit)
+	if err != nil {
+		log.Fatal(err)
+	}
+	injection, err := newInjectionPolicy(gw.Moderation.Injection)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// gRPC 客户端
-	tokenCli := pb.NewTokenServiceClient(tokenConn)
-	filterCli := pb.NewFilterServiceClient(filterConn)
-	historyCli := pb.NewHistoryServiceClient(historyConn)
-	llmCli := pb.NewLLMServiceClient(llmConn)
+	p := &pipeline{
+		token:       pb.NewTokenServiceClient(tokenConn),
+		filter:      pb.NewFilterServiceClient(filterConn),
+		filterAdmin: pb.NewFilterAdminServiceClient(filterConn),
+		history:     pb.NewHistoryServiceClient(historyConn),
+		llm:         pb.NewLLMServiceClient(llmConn),
+		auth:        pb.NewAuthServiceClient(authConn),
+		limiter:     limiter,
+		injection:   injection,
+		cfg:         gw,
+	}
 
 	// Gin 路由
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery(), httpMetrics, otelgin.Middleware("gateway", otelgin.WithGinFilter(traced)), traceHeader, accessLog)
 
 	// 静态前端（可选）：访问 http://localhost:8080/
 	r.StaticFile("/", "./web/index.html")
 
-	// 简单限流（与 Free 3 RPM 对齐；多实例需分布式限流）
-	limiter := rate.NewLimiter(rate.Every(time.Minute/3), 3) // 3 次/分钟，突发 3
-
-	// 请求体
-	type chatReq struct {
-		UserID string `json:"user_id"`
-		Text   string `json:"text"`
-	}
-
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) {
 		c.String(http.StatusOK, "ok")
 	})
 
-	// 查询历史
-	r.GET("/history", func(c *gin.Context) {
-		user := c.Query("user_id")
-		if user == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "missing user_id"})
-			return
-		}
-		ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
-		defer cancel()
-		resp, err := historyCli.List(ctx, &pb.ListRequest{UserId: user, Limit: 20})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "history failed", "detail": err.Error()})
-			return
-		}
-		c.JSON(http.StatusOK, resp.Items)
-	})
+	// Prometheus 指标
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 
-	// 核心入口：HTTP → (Filter → Token 预占 → LLM → Token 对齐 → Save History)
-	r.POST("/chat", func(c *gin.Context) {
-		// 限流
-		if err := limiter.Wait(c.Request.Context()); err != nil {
-			c.JSON(http.StatusTooManyRequests, gin.H{"error": "rate_limited"})
-			return
-		}
+	// 以下接口都要求 API Key（Authorization: Bearer sk-...），用户身份取自 key
+	api := r.Group("/", p.authenticate)
 
-		var req chatReq
-		if err := c.BindJSON(&req); err != nil || req.UserID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "bad json or missing user_id"})
+	// 查询历史
+	api.GET("/history", requireScope(scopeHistoryRead), p.listHistory)
+
+	// 会话管理
+	api.GET("/conversations", requireScope(scopeHistoryRead), p.listConversations)
+	api.POST("/conversations", requireScope(scopeChat), p.createConversation)
+	api.PATCH("/conversations/:id", requireScope(scopeChat), p.renameConversation)
+	api.DELETE("/conversations/:id", requireScope(scopeChat), p.deleteConversation)
+	api.GET("/conversations/:id/messages", requireScope(scopeHistoryRead), p.conversationMessages)
+
+	// API Key 管理
+	admin := r.Group("/admin", p.authenticate, requireScope(scopeAdmin))
+	admin.POST("/keys", p.issueKey)
+	admin.GET("/keys", p.listKeys)
+	admin.POST("/keys/:id/rotate", p.rotateKey)
+	admin.DELETE("/keys/:id", p.revokeKey)
+
+	// 内容审核：被拦截的请求
+	admin.GET("/moderation/blocked", p.listBlocked)
+	admin.POST("/moderation/dry-run", requireGlobalAdmin, p.dryRun)
+
+	// 过滤规则管理（filter.store=mysql）：改动生成新版本，激活后生效
+	rules := admin.Group("/filter", requireGlobalAdmin)
+	rules.GET("/policies", p.listPolicies)
+	rules.POST("/policies/:version/activate", p.activatePolicy)
+	rules.GET("/rules", p.listRules)
+	rules.POST("/rules", p.createRule)
+	rules.PUT("/rules/:id", p.updateRule)
+	rules.DELETE("/rules/:id", p.deleteRule)
+	rules.GET("/audit", p.listFilterAudit)
+
+	// 核心入口：HTTP → (Filter → Token 预占 → LLM → Token 结算 → 审核回复 → Save History)
+	api.POST("/chat", requireScope(scopeChat), func(c *gin.Context) {
+		req, ok := p.bindChat(c)
+		if !ok {
 			return
 		}
 
 		// 根上下文（绑定到本次 HTTP 请求）
 		root := c.Request.Context()
 
-		// 1) 文本过滤 / 清洗（本地 gRPC，800ms）
-		fctx, fcancel := context.WithTimeout(root, 800*time.Millisecond)
-		defer fcancel()
-
-		fr, err := filterCli.Filter(fctx, &pb.FilterRequest{Text: req.Text})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "filter failed", "detail": err.Error()})
+		// 1) 上下文与文本过滤 + 2) 预占配额
+		fr, res, ok := p.prepare(c, &req)
+		if !ok {
 			return
 		}
-		if !fr.GetAllowed() {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "text blocked by filter"})
-			return
-		}
-
-		// 2) 预占配额（本地 gRPC，800ms）
-		tctx, tcancel := context.WithTimeout(root, 800*time.Millisecond)
-		defer tcancel()
+		defer p.release(root, res) // 任一出错路径都退回预占；commit 后为空操作
 
-		const preReserve = int32(200) // 先预占 200 tokens，调用后用真实用量对齐
-		tr1, err := tokenCli.CheckAndInc(tctx, &pb.TokenRequest{
-			UserId: req.UserID, Tokens: preReserve,
-		})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "token failed", "detail": err.Error()})
-			return
-		}
-		if !tr1.GetAllowed() {
-			c.JSON(http.StatusTooManyRequests, gin.H{"error": "quota exceeded", "remaining": tr1.GetRemaining()})
-			return
-		}
+		conv, history := p.conversation(c, req)
+		defer p.discard(root, conv) // 新建的会话没保存成功就删掉，不留下空会话
 
-		// 3) 调用 LLM（外部服务，给 12s）
-		lctx, lcancel := context.WithTimeout(root, 12*time.Second)
+		// 3) 调用 LLM（外部服务，默认给 12s）
+		lctx, lcancel := context.WithTimeout(root, gw.Timeouts.LLM.Duration)
 		defer lcancel()
 
-		lr, err := llmCli.Generate(lctx, &pb.ChatRequest{
-			UserId: req.UserID, Text: fr.GetCleaned(),
+		lr, err := p.llm.Generate(lctx, &pb.ChatRequest{
+			UserId: req.UserID, Text: fr.GetCleaned(), History: history, Model: req.Model,
 		})
 		if err != nil {
-			msg := err.Error()
-			// 额度不足（需要充值或开通计费）
-			if strings.Contains(msg, "insufficient_quota") {
-				c.JSON(http.StatusPaymentRequired, gin.H{
-					"error":  "insufficient_quota",
-					"detail": "OpenAI 项目无可用额度：请在 Billing 中添加支付方式或购买 credits 后再试",
-				})
-				return
-			}
-			// 速率限制（429）
-			if strings.Contains(msg, "Too Many Requests") || strings.Contains(msg, "rate limit") {
-				c.JSON(http.StatusTooManyRequests, gin.H{
-					"error":  "rate_limited",
-					"detail": "触发速率限制，稍后重试或降低并发/频率",
-				})
-				return
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "llm failed", "detail": msg})
+			writeLLMError(c, err)
 			return
 		}
 
-		// 4) 依据真实用量对齐配额（LLM 返回 usage.total_tokens）
-		finalRemaining := tr1.GetRemaining()
-		if tt := lr.GetTotalTokens(); tt > 0 {
-			delta := tt - preReserve // 正数=补扣，负数=回冲
-			if delta != 0 {
-				actx, acancel := context.WithTimeout(root, 800*time.Millisecond)
-				defer acancel()
-				if tr2, err := tokenCli.CheckAndInc(actx, &pb.TokenRequest{
-					UserId: req.UserID, Tokens: delta,
-				}); err == nil {
-					finalRemaining = tr2.GetRemaining()
-				}
-				// 如果对齐失败，不影响本次请求成功返回；remaining 使用预占时的值
-			}
-		}
-
-		// 5) 保存历史（非阻塞性，失败也不影响本次响应）
-		hctx, hcancel := context.WithTimeout(root, 800*time.Millisecond)
-		defer hcancel()
-		_, _ = historyCli.Save(hctx, &pb.SaveRequest{UserId: req.UserID, Role: "user", Text: req.Text})
-		_, _ = historyCli.Save(hctx, &pb.SaveRequest{UserId: req.UserID, Role: "assistant", Text: lr.GetReply()})
+		// 4) 依据真实用量结算预占
+		finalRemaining := p.commit(root, res, lr.GetTotalTokens())
 
-		// 6) 返回结果（包含 usage 便于对账/展示）
-		c.JSON(http.StatusOK, gin.H{
-			"cleaned": fr.GetCleaned(),
-			"reply":   lr.GetReply(),
+		resp := gin.H{
+			"conversation_id": conv.id,
+			"cleaned":         fr.GetCleaned(),
+			"pii":             piiSummary(fr.GetPii()),
+			"model":           lr.GetModel(),
 			"usage": gin.H{
 				"prompt_tokens":     lr.GetPromptTokens(),
 				"completion_tokens": lr.GetCompletionTokens(),
 				"total_tokens":      lr.GetTotalTokens(),
 			},
 			"remaining": finalRemaining,
-		})
+		}
+		if w := injectionWarnings(c); w != nil {
+			resp["warnings"] = w
+		}
+
+		// 5) 审核回复：被拦截的回复不返回、不保存（已记入拦截事件），token 照常计费
+		mr, err := p.checkReply(root, req.UserID, lr.GetReply(), false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "filter failed", "detail": err.Error()})
+			return
+		}
+		if !mr.GetAllowed() {
+			resp["reply"] = ""
+			resp["finish_reason"] = "content_filter"
+			resp["reason"] = blockReason(c, mr, pb.FilterDirection_OUTPUT)
+			resp["ruleset_version"] = mr.GetRulesetVersion()
+			c.JSON(http.StatusOK, resp)
+			return
+		}
+
+		// 6) 保存历史：个人敏感信息只以占位符落库
+		p.saveHistory(root, conv, storedText(req.Text, fr), mr.GetCleaned())
+
+		// 7) 返回结果（包含 usage 便于对账/展示）；策略允许时把回复里的占位符换回原值
+		resp["reply"] = newPIIRestorer(fr.GetPii()).restore(mr.GetCleaned())
+		resp["finish_reason"] = "stop"
+		c.JSON(http.StatusOK, resp)
 	})
 
+	// 流式入口（SSE）：边生成边推送，步骤同 /chat
+	api.POST("/chat/stream", requireScope(scopeChat), p.chatStream)
+
 	// 启动 HTTP 网关
 	// 示例：
 	// curl -s -X POST http://localhost:8080/chat \
-	//   -H 'Content-Type: application/json' \
-	//   -d '{"user_id":"u1","text":"Hello   world   from   Go!"}'
-	r.Run(":8080")
+	//   -H "Authorization: Bearer $API_KEY" -H 'Content-Type: application/json' \
+	//   -d '{"text":"Hello   world   from   Go!"}'
+	// curl -N -X POST http://localhost:8080/chat/stream \
+	//   -H "Authorization: Bearer $API_KEY" -H 'Content-Type: application/json' \
+	//   -d '{"text":"讲个笑话"}'
+	slog.Info("gateway listening", "addr", gw.Addr)
+	if err := r.Run(gw.Addr); err != nil {
+		log.Fatal(err)
+	}
 }
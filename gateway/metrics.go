@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"chatgpt-demo/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests handled by the gateway, by route, method and status.",
+	}, []string{"route", "method", "status"})
+	httpSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency (SSE: until the stream ends), by route and method.",
+		Buckets: metrics.LatencyBuckets,
+	}, []string{"route", "method"})
+	llmErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_llm_errors_total",
+		Help: "LLM failures returned to clients, by error class.",
+	}, []string{"error"})
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ratelimit_requests_total",
+		Help: "Rate limiter decisions, one per request: allowed (including after queueing) or rejected.",
+	}, []string{"result"})
+	rateLimitQueue = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ratelimit_queue_total",
+		Help: "Queue mode outcomes: full (could not queue), allowed or rejected after waiting in the queue.",
+	}, []string{"result"})
+	rateLimitWait = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "ratelimit_queue_wait_seconds",
+		Help:    "Time requests spent waiting in the rate limit queue.",
+		Buckets: metrics.LatencyBuckets,
+	})
+)
+
+// httpMetrics 记录每个 HTTP 请求；route 取路由模板（如 /conversations/:id），避免 ID 撑爆标签
+func httpMetrics(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequests.WithLabelValues(route, c.Request.Method, strconv.Itoa(c.Writer.Status())).Inc()
+	httpSeconds.WithLabelValues(route, c.Request.Method).Observe(time.Since(start).Seconds())
+}
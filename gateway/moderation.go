@@ -0,0 +1,331 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 拦截原因的本地化文案：分类 → 语言 → 文案。新增分类时在这里补上，缺的分类退回 other
+var reasonMessages = map[string]map[string]string{
+	"profanity": {"zh": "内容包含不文明用语，请修改后重试。", "en": "Your message contains profanity. Please rephrase and try again."},
+	"hate":      {"zh": "内容包含仇恨或歧视性言论。", "en": "Your message contains hateful or discriminatory language."},
+	"sexual":    {"zh": "内容包含色情信息。", "en": "Your message contains sexual content."},
+	"violence":  {"zh": "内容包含暴力信息。", "en": "Your message contains violent content."},
+	"self_harm": {"zh": "内容涉及自我伤害。如果你正在经历困难，请向身边的人或专业机构求助。", "en": "Your message mentions self-harm. If you are struggling, please reach out to someone you trust or a professional."},
+	"illegal":   {"zh": "内容涉及违法信息。", "en": "Your message refers to illegal activity."},
+	"pii":       {"zh": "内容包含个人敏感信息（如手机号、证件号），请删除后重试。", "en": "Your message contains personal information (such as a phone or ID number). Please remove it and try again."},
+	"injection": {"zh": "内容疑似试图绕过系统指令。", "en": "Your message looks like an attempt to override system instructions."},
+	"spam":      {"zh": "内容疑似垃圾信息。", "en": "Your message looks like spam."},
+	"other":     {"zh": "内容不符合使用规范。", "en": "Your message violates the usage policy."},
+}
+
+// 支持的语言，第一个为默认
+var reasonLocales = []string{"zh", "en"}
+
+// span 是一处命中，[start, end) 为请求 text 里的 Unicode 码点下标（JavaScript 里按 Array.from(text) 取）
+type span struct {
+	RuleID   string `json:"rule_id"`
+	Category string `json:"category"`
+	Severity string `json:"severity"`
+	Start    int32  `json:"start"`
+	End      int32  `json:"end"`
+}
+
+func spans(ms []*pb.FilterMatch) []span {
+	out := make([]span, len(ms))
+	for i, m := range ms {
+		out[i] = span{RuleID: m.GetRuleId(), Category: m.GetCategory(), Severity: m.GetSeverity(), Start: m.GetStart(), End: m.GetEnd()}
+	}
+	return out
+}
+
+// 模型回复被拦截时的文案，不区分分类（分类见 reason.category）
+var replyBlockedMessages = map[string]string{
+	"zh": "回复内容不符合使用规范，已被拦截。",
+	"en": "The reply was withheld because it violates the usage policy.",
+}
+
+// writeBlocked 返回 400 与结构化的拦截原因：
+//
+//	{"error":"text blocked by filter","ruleset_version":"...",
+//	 "reason":{"code":"content_blocked.profanity","category":"profanity","severity":"medium",
+//	           "message":"内容包含不文明用语…","locale":"zh","matches":[{"rule_id":"...","start":0,"end":3,...}]}}
+//
+// code 稳定、可供客户端自行翻译；message 按 Accept-Language（zh/en，默认 zh）给出。
+func writeBlocked(c *gin.Context, fr *pb.FilterReply) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":           "text blocked by filter",
+		"ruleset_version": fr.GetRulesetVersion(),
+		"reason":          blockReason(c, fr, pb.FilterDirection_INPUT),
+	})
+}
+
+// blockReason 生成拦截原因；模型回复被拦截时 code 为 reply_blocked.<category>
+func blockReason(c *gin.Context, fr *pb.FilterReply, dir pb.FilterDirection) gin.H {
+	cat := fr.GetCategory()
+	msgs, ok := reasonMessages[cat]
+	if !ok {
+		cat, msgs = "other", reasonMessages["other"]
+	}
+	loc := locale(c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", loc)
+	code, msg := "content_blocked."+cat, msgs[loc]
+	if dir == pb.FilterDirection_OUTPUT {
+		code, msg = "reply_blocked."+cat, replyBlockedMessages[loc]
+	}
+	return gin.H{
+		"code":     code,
+		"category": cat,
+		"severity": fr.GetSeverity(),
+		"message":  msg,
+		"locale":   loc,
+		"matches":  spans(fr.GetMatches()),
+	}
+}
+
+// checkReply 审核一段模型回复（OUTPUT 方向）；partial 为流式中途的审核（不计放行时的指标，见 FilterRequest.partial）。
+// 未开启审核、或 filterserver 出错且配置了 fail_open 时原样放行
+func (p *pipeline) checkReply(ctx context.Context, user, text string, partial bool) (*pb.FilterReply, error) {
+	if !p.cfg.Moderation.Output {
+		return &pb.FilterReply{Allowed: true, Cleaned: text}, nil
+	}
+	fctx, cancel := context.WithTimeout(ctx, p.cfg.Timeouts.RPC.Duration)
+	defer cancel()
+	fr, err := p.filter.Filter(fctx, &pb.FilterRequest{Text: text, UserId: user, Direction: pb.FilterDirection_OUTPUT, Partial: partial})
+	if err != nil && p.cfg.Moderation.FailOpen {
+		logging.FromContext(ctx).Warn("reply moderation failed, passing reply through", "error", err)
+		return &pb.FilterReply{Allowed: true, Cleaned: text}, nil
+	}
+	return fr, err
+}
+
+// replyModerator 边生成边审核流式回复：每积累 StreamChunk 个字，就把新增部分连同之前末尾的一段
+// （已推送部分的最后 StreamHold 个字 + 暂扣的 StreamHold 个字）交给 filterserver，每次审核的长度有上限，
+// 整条回复的审核量随长度线性增长；审核通过的部分除末尾 StreamHold 个字外推送给客户端。
+// 流结束时再对全文审核一次，以它为准（跨度超过窗口的命中也能识别）。
+// redact 逐字替换、长度不变，所以已推送的位置始终对得上；中途拦截后流即中断，一次拦截只记一条拦截事件。
+type replyModerator struct {
+	p    *pipeline
+	ctx  context.Context
+	user string
+
+	raw     []rune // 模型原文
+	clean   []rune // 已审核的前缀（redact 之后），长度即已审核的字数
+	sent    int    // 已推送的字数
+	blocked *pb.FilterReply
+}
+
+func (p *pipeline) newReplyModerator(ctx context.Context, user string) *replyModerator {
+	return &replyModerator{p: p, ctx: ctx, user: user}
+}
+
+// write 追加一段增量，返回现在可以推送的文本；被拦截时 m.blocked 非空
+func (m *replyModerator) write(delta string) (string, error) {
+	m.raw = append(m.raw, []rune(delta)...)
+	if !m.p.cfg.Moderation.Output {
+		return delta, nil
+	}
+	if len(m.raw)-len(m.clean) < m.p.cfg.Moderation.StreamChunk {
+		return "", nil
+	}
+	if err := m.check(max(0, m.sent-m.p.cfg.Moderation.StreamHold), true); err != nil || m.blocked != nil {
+		return "", err
+	}
+	return m.release(len(m.clean) - m.p.cfg.Moderation.StreamHold), nil
+}
+
+// flush 在流结束时审核全文，返回尚未推送的全部文本
+func (m *replyModerator) flush() (string, error) {
+	if !m.p.cfg.Moderation.Output {
+		return "", nil
+	}
+	if len(m.raw) > 0 {
+		if err := m.check(0, false); err != nil || m.blocked != nil {
+			return "", err
+		}
+	}
+	return m.release(len(m.clean)), nil
+}
+
+// check 审核 raw[from:]，用结果替换 clean 里 from 之后的部分；拦截时命中位置换算回整条回复。
+// 中途的审核（partial）不计影子规则、遮盖等指标，只有流结束时的全文审核计一次
+func (m *replyModerator) check(from int, partial bool) error {
+	fr, err := m.p.checkReply(m.ctx, m.user, string(m.raw[from:]), partial)
+	if err != nil {
+		return err
+	}
+	if !fr.GetAllowed() {
+		for _, mt := range fr.GetMatches() {
+			mt.Start += int32(from)
+			mt.End += int32(from)
+		}
+		m.blocked = fr
+		return nil
+	}
+	m.clean = append(m.clean[:from], []rune(fr.GetCleaned())...)
+	return nil
+}
+
+func (m *replyModerator) release(upto int) string {
+	if upto <= m.sent {
+		return ""
+	}
+	s := string(m.clean[m.sent:upto])
+	m.sent = upto
+	return s
+}
+
+// text 返回审核后的完整回复（用于保存历史）
+func (m *replyModerator) text() string {
+	if !m.p.cfg.Moderation.Output {
+		return string(m.raw)
+	}
+	return string(m.clean)
+}
+
+// locale 按 Accept-Language 的 q 值选出支持的语言（只看主标签：zh-CN、zh-Hant 都算 zh），没有匹配时用默认语言
+func locale(header string) string {
+	type tag struct {
+		lang string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(lang), "-")
+		if q > 0 && slices.Contains(reasonLocales, primary) {
+			tags = append(tags, tag{primary, q})
+		}
+	}
+	if len(tags) == 0 {
+		return reasonLocales[0]
+	}
+	// 稳定排序：q 相同保持客户端给出的顺序
+	slices.SortStableFunc(tags, func(a, b tag) int { return cmp.Compare(b.q, a.q) })
+	return tags[0].lang
+}
+
+// blockedEvent 是 GET /admin/moderation/blocked 的一项
+type blockedEvent struct {
+	ID             string `json:"id"`
+	CreatedAt      int64  `json:"created_at"`
+	UserID         string `json:"user_id"`
+	RequestID      string `json:"request_id"`
+	RulesetVersion string `json:"ruleset_version"`
+	Category       string `json:"category"`
+	Severity       string `json:"severity"`
+	Direction      string `json:"direction"` // input | output（被拦截的是模型回复）
+	Text           string `json:"text"`
+	Matches        []span `json:"matches"`
+}
+
+// GET /admin/moderation/blocked?tenant_id=t1&limit=20&cursor=...：被拦截的请求，按时间倒序，供人工复核。
+// 绑定租户的管理员只能看本租户用户的事件；下一页游标放在 X-Next-Cursor 响应头。
+func (p *pipeline) listBlocked(c *gin.Context) {
+	tenant := c.Query("tenant_id")
+	if t := adminTenant(c); t != "" {
+		tenant = t
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.filter.ListBlocked(ctx, &pb.ListBlockedRequest{
+		TenantId: tenant, Limit: int32(limit), Cursor: c.Query("cursor"),
+	})
+	if err != nil {
+		writeRPCError(c, "filter failed", err)
+		return
+	}
+	if nc := resp.GetNextCursor(); nc != "" {
+		c.Header("X-Next-Cursor", nc)
+	}
+	out := make([]blockedEvent, len(resp.GetEvents()))
+	for i, e := range resp.GetEvents() {
+		out[i] = blockedEvent{
+			ID: e.GetId(), CreatedAt: e.GetCreatedAt(), UserID: e.GetUserId(), RequestID: e.GetRequestId(),
+			RulesetVersion: e.GetRulesetVersion(), Category: e.GetCategory(), Severity: e.GetSeverity(),
+			Direction: strings.ToLower(e.GetDirection().String()),
+			Text:      e.GetText(), Matches: spans(e.GetMatches()),
+		}
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+type dryRunReq struct {
+	Policy        string   `json:"policy"`            // 候选规则文件（YAML 全文）
+	Version       string   `json:"candidate_version"` // 或者 MySQL 里保存的规则版本（如 v12）；都为空时用当前规则
+	Texts         []string `json:"texts"`
+	Direction     string   `json:"direction"` // input（默认）| output
+	PromoteShadow bool     `json:"promote_shadow"`
+}
+
+type dryRunResult struct {
+	Index              int32   `json:"index"`
+	CurrentBlocked     bool    `json:"current_blocked"`
+	CandidateBlocked   bool    `json:"candidate_blocked"`
+	Category           string  `json:"category,omitempty"`
+	Severity           string  `json:"severity,omitempty"`
+	Matches            []span  `json:"matches"`
+	InjectionScore     float32 `json:"injection_score"`
+	InjectionSuspected bool    `json:"injection_suspected"`
+}
+
+// POST /admin/moderation/dry-run：用候选规则判定一批文本，与当前规则对比拦截率，不记录拦截事件。
+// 规则对所有租户生效，只有全局管理员可以调用（requireGlobalAdmin）。
+func (p *pipeline) dryRun(c *gin.Context) {
+	var req dryRunReq
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+		return
+	}
+	dir, ok := pb.FilterDirection_value[strings.ToUpper(cmp.Or(req.Direction, "input"))]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "direction must be input or output"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.Query.Duration)
+	defer cancel()
+	resp, err := p.filter.DryRun(ctx, &pb.DryRunRequest{
+		Policy: req.Policy, CandidateVersion: req.Version, Texts: req.Texts, Direction: pb.FilterDirection(dir), PromoteShadow: req.PromoteShadow,
+	})
+	if err != nil {
+		writeRPCError(c, "filter failed", err)
+		return
+	}
+	results := make([]dryRunResult, len(resp.GetResults()))
+	for i, r := range resp.GetResults() {
+		results[i] = dryRunResult{
+			Index: r.GetIndex(), CurrentBlocked: r.GetCurrentBlocked(), CandidateBlocked: r.GetCandidateBlocked(),
+			Category: r.GetCategory(), Severity: r.GetSeverity(), Matches: spans(r.GetMatches()),
+			InjectionScore: r.GetInjectionScore(), InjectionSuspected: r.GetInjectionSuspected(),
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"current_version":      resp.GetCurrentVersion(),
+		"candidate_version":    resp.GetCandidateVersion(),
+		"total":                resp.GetTotal(),
+		"current_blocked":      resp.GetCurrentBlocked(),
+		"candidate_blocked":    resp.GetCandidateBlocked(),
+		"newly_blocked":        resp.GetNewlyBlocked(),
+		"newly_allowed":        resp.GetNewlyAllowed(),
+		"candidate_categories": resp.GetCandidateCategories(),
+		"results":              results,
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	pb "chatgpt-demo/chatpb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// piiRestorer 在本次请求内把 LLM 回复里的占位符（[PHONE_1]…）换回原值。
+// 只包含策略允许回填（filterserver 返回了 value）的项；映射只在内存里，保存历史用的仍是占位符。
+// nil 表示没有可回填的项，方法原样返回输入。
+type piiRestorer struct {
+	r       *strings.Replacer
+	maxLen  int    // 最长占位符的字节数
+	pending string // 流式：末尾可能是被截断的占位符，留到下一段再输出
+}
+
+func newPIIRestorer(entities []*pb.PiiEntity) *piiRestorer {
+	var pairs []string
+	maxLen := 0
+	seen := map[string]bool{}
+	for _, e := range entities {
+		if e.GetValue() == "" || seen[e.GetPlaceholder()] {
+			continue
+		}
+		seen[e.GetPlaceholder()] = true
+		pairs = append(pairs, e.GetPlaceholder(), e.GetValue())
+		maxLen = max(maxLen, len(e.GetPlaceholder()))
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+	return &piiRestorer{r: strings.NewReplacer(pairs...), maxLen: maxLen}
+}
+
+// restore 替换一段完整文本（非流式回复）
+func (p *piiRestorer) restore(s string) string {
+	if p == nil {
+		return s
+	}
+	return p.r.Replace(s)
+}
+
+// write 处理流式回复的一段增量，返回可以立即推送的部分；
+// 末尾未闭合、可能是占位符开头的 "[…" 暂存，等下一段或 flush
+func (p *piiRestorer) write(delta string) string {
+	if p == nil {
+		return delta
+	}
+	buf := p.pending + delta
+	p.pending = ""
+	if i := strings.LastIndexByte(buf, '['); i >= 0 && !strings.Contains(buf[i:], "]") && len(buf)-i < p.maxLen {
+		buf, p.pending = buf[:i], buf[i:]
+	}
+	return p.r.Replace(buf)
+}
+
+// flush 返回流结束时暂存的剩余部分
+func (p *piiRestorer) flush() string {
+	if p == nil {
+		return ""
+	}
+	s := p.pending
+	p.pending = ""
+	return p.r.Replace(s)
+}
+
+// placeholderRe 匹配个人敏感信息的占位符：[PHONE_1]、[CN_ID_2]…
+var placeholderRe = regexp.MustCompile(`\[([A-Z][A-Z_]*)_([0-9]+)\]`)
+
+// notePlaceholders 把 text 里出现的占位符编号记进 seq（标签 → 最大编号），同一请求里后面的文本接着编号
+func notePlaceholders(seq map[string]int32, text string) {
+	for _, m := range placeholderRe.FindAllStringSubmatch(text, -1) {
+		if n, err := strconv.ParseInt(m[2], 10, 32); err == nil && int32(n) > seq[m[1]] {
+			seq[m[1]] = int32(n)
+		}
+	}
+}
+
+// storedText 返回写入历史的用户消息：有个人敏感信息被遮盖时用遮盖后的文本，否则保留原文
+func storedText(text string, fr *pb.FilterReply) string {
+	if len(fr.GetPii()) > 0 {
+		return fr.GetCleaned()
+	}
+	return text
+}
+
+// piiSummary 是响应里的遮盖明细（不含原值），客户端据此提示“已隐藏手机号”等
+func piiSummary(entities []*pb.PiiEntity) []gin.H {
+	out := make([]gin.H, len(entities))
+	for i, e := range entities {
+		out[i] = gin.H{"type": e.GetType(), "placeholder": e.GetPlaceholder(), "start": e.GetStart(), "end": e.GetEnd()}
+	}
+	return out
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/config"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 每次最多从历史服务加载的上下文条数（再由 llmserver 按 token 预算裁剪）
+const contextTurns = 20
+
+// 请求体
+type chatReq struct {
+	// 由 API Key 决定；只有租户 key 需要在这里指定终端用户
+	UserID string `json:"user_id"`
+	Text   string `json:"text"`
+	// 可选：指定模型（须在 llmserver 白名单内）
+	Model string `json:"model"`
+	// 可选：所属会话；为空时新建一个
+	ConversationID string `json:"conversation_id"`
+	// 可选：显式给出上下文（按时间顺序）；为空时从会话历史加载
+	History []chatMsg `json:"history"`
+}
+
+type chatMsg struct {
+	Role string `json:"role"` // user | assistant；系统指令只能由服务端设置
+	Text string `json:"text"`
+}
+
+// pipeline 持有各后端 gRPC 客户端，封装 /chat 与 /chat/stream 共用的步骤
+type pipeline struct {
+	token  pb.TokenServiceClient
+	filter pb.FilterServiceClient
+	// 规则管理（filterserver 同一个端口）
+	filterAdmin pb.FilterAdminServiceClient
+	history     pb.HistoryServiceClient
+	llm         pb.LLMServiceClient
+	auth        pb.AuthServiceClient
+	limiter     *rateLimiter
+	injection   *injectionPolicy // 疑似提示词注入的租户策略
+	cfg         config.Gateway   // 超时、预占额度等
+}
+
+// reservation 是一次配额预占；commit 之后 release 为空操作
+type reservation struct {
+	id        string
+	user      string
+	remaining int64
+	done      bool
+}
+
+// chatConv 是本轮所属的会话。本轮新建（created）的会话没能保存这一轮（LLM 出错、回复被拦截、客户端断开等）时
+// 由 discard 删除，会话列表里不留下空会话；历史服务不可用时 id 为空
+type chatConv struct {
+	id      string
+	user    string
+	created bool
+	saved   bool
+}
+
+// bindChat 解析请求体，按 API Key 确定用户身份并限流。
+// 失败时已经写好 HTTP 响应，调用方直接 return 即可。
+func (p *pipeline) bindChat(c *gin.Context) (req chatReq, ok bool) {
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad json"})
+		return req, false
+	}
+	for _, m := range req.History {
+		if m.Role != "user" && m.Role != "assistant" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request", "detail": "history role must be user or assistant"})
+			return req, false
+		}
+	}
+	// 与从会话加载时一样只保留最近几轮，每条都要过一次 Filter
+	if len(req.History) > contextTurns {
+		req.History = req.History[len(req.History)-contextTurns:]
+	}
+	if req.UserID, ok = userID(c, req.UserID); !ok {
+		return req, false
+	}
+	return req, p.limiter.allow(c, req.UserID)
+}
+
+// prepare 确定上下文 → Filter → Token 预占，返回 text 的过滤结果（清洗/遮盖后的文本在 cleaned）：
+//   - 指定了 conversation_id：加载该会话最近几轮（保存的已是遮盖后的文本）；会话不存在或不属于该用户时返回 404
+//   - 请求体显式给出 history 时以它为上下文：逐条同样过滤，并就地换成清洗/遮盖后的文本
+//
+// 上下文与 text 的个人敏感信息占位符共用一套编号（FilterRequest.pii_offsets），回复里的占位符不会被换成别的值；
+// text 与显式 history 一起按提示词注入的租户策略处理。上下文放在 req.History 里交给 conversation。
+// 失败时已经写好 HTTP 响应，调用方直接 return 即可。
+func (p *pipeline) prepare(c *gin.Context, req *chatReq) (fr *pb.FilterReply, res *reservation, ok bool) {
+	root := c.Request.Context()
+
+	// 1) 上下文（本地 gRPC，每次 800ms）；seq 记下上下文里已用掉的占位符编号
+	stored, ok := p.storedHistory(c, *req)
+	if !ok {
+		return nil, nil, false
+	}
+	seq := map[string]int32{}
+	hrs, ok := p.filterHistory(c, *req, seq)
+	if !ok {
+		return nil, nil, false
+	}
+	if len(req.History) == 0 {
+		req.History = stored
+		for _, m := range stored {
+			notePlaceholders(seq, m.Text)
+		}
+	}
+
+	// 2) 文本过滤 / 清洗：占位符接着上下文编号
+	fctx, fcancel := context.WithTimeout(root, p.cfg.Timeouts.RPC.Duration)
+	defer fcancel()
+
+	fr, err := p.filter.Filter(fctx, &pb.FilterRequest{Text: req.Text, UserId: req.UserID, PiiOffsets: seq})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "filter failed", "detail": err.Error()})
+		return nil, nil, false
+	}
+	if !fr.GetAllowed() {
+		writeBlocked(c, fr)
+		return nil, nil, false
+	}
+	if !p.checkInjection(c, *req, fr, hrs) {
+		return nil, nil, false
+	}
+	// 与 text 一样只把清洗/遮盖后的文本交给模型，个人敏感信息不出网关
+	for i, hr := range hrs {
+		req.History[i].Text = hr.GetCleaned()
+	}
+
+	// 3) 预占配额（本地 gRPC，800ms）；超时未结算的预占由 tokenserver 自动回收
+	tctx, tcancel := context.WithTimeout(root, p.cfg.Timeouts.RPC.Duration)
+	defer tcancel()
+
+	tr, err := p.token.Reserve(tctx, &pb.ReserveRequest{
+		UserId: req.UserID, Tokens: p.cfg.PreReserve,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "token failed", "detail": err.Error()})
+		return nil, nil, false
+	}
+	if !tr.GetAllowed() {
+		writeQuotaExceeded(c, tr)
+		return nil, nil, false
+	}
+
+	return fr, &reservation{
+		id: tr.GetReservationId(), user: req.UserID, remaining: tr.GetRemaining(),
+	}, true
+}
+
+// storedHistory 加载 conversation_id 所指会话的最近几轮（按时间顺序）；没有指定会话时返回空。
+// 会话不存在或不属于该用户时返回 404；历史服务不可用时退化为无上下文，不阻断对话。
+// 失败时已经写好 HTTP 响应，调用方直接 return 即可。
+func (p *pipeline) storedHistory(c *gin.Context, req chatReq) ([]chatMsg, bool) {
+	if req.ConversationID == "" {
+		return nil, true
+	}
+	hctx, hcancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.RPC.Duration)
+	defer hcancel()
+	resp, err := p.history.List(hctx, &pb.ListRequest{
+		UserId: req.UserID, ConversationId: req.ConversationID, Limit: contextTurns,
+	})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.NotFound, codes.InvalidArgument:
+		writeRPCError(c, "conversation not found", err)
+		return nil, false
+	default:
+		return nil, true
+	}
+	// List 返回最近在前，这里翻转为时间顺序
+	items := resp.GetItems()
+	out := make([]chatMsg, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		out = append(out, chatMsg{Role: items[i].GetRole(), Text: items[i].GetText()})
+	}
+	return out, true
+}
+
+// filterHistory 把请求体显式给出的 history 逐条交给 filterserver（INPUT 方向，与 text 相同），
+// 占位符按顺序接着编号，用掉的编号记进 seq；任一条被拦截即返回 400。
+// 返回各条的过滤结果，顺序与 req.History 一致。失败时已经写好 HTTP 响应，调用方直接 return 即可。
+func (p *pipeline) filterHistory(c *gin.Context, req chatReq, seq map[string]int32) ([]*pb.FilterReply, bool) {
+	out := make([]*pb.FilterReply, len(req.History))
+	for i, m := range req.History {
+		fctx, fcancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.RPC.Duration)
+		fr, err := p.filter.Filter(fctx, &pb.FilterRequest{Text: m.Text, UserId: req.UserID, PiiOffsets: seq, History: true})
+		fcancel()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "filter failed", "detail": err.Error()})
+			return nil, false
+		}
+		if !fr.GetAllowed() {
+			writeBlocked(c, fr)
+			return nil, false
+		}
+		notePlaceholders(seq, fr.GetCleaned())
+		out[i] = fr
+	}
+	return out, true
+}
+
+// commit 依据真实用量结算预占（LLM 返回 usage.total_tokens），返回最新 remaining。
+// 没有 usage 时按预占额计费；结算失败不影响本次请求成功返回，remaining 使用预占时的值。
+func (p *pipeline) commit(root context.Context, res *reservation, total int32) int64 {
+	res.done = true
+	if total <= 0 {
+		total = p.cfg.PreReserve
+	}
+	// 客户端已断开也要结算，不跟随请求取消
+	actx, acancel := context.WithTimeout(context.WithoutCancel(root), p.cfg.Timeouts.RPC.Duration)
+	defer acancel()
+	tr, err := p.token.Commit(actx, &pb.CommitRequest{
+		UserId: res.user, ReservationId: res.id, Tokens: total,
+	})
+	if err != nil {
+		return res.remaining
+	}
+	return tr.GetRemaining()
+}
+
+// release 退回未结算的预占。handler 里 defer 调用，覆盖 LLM 失败、客户端断开、panic 等出错路径；
+// 网关进程崩溃时则由 tokenserver 按过期时间回收。
+func (p *pipeline) release(root context.Context, res *reservation) {
+	if res.done {
+		return
+	}
+	res.done = true
+	rctx, rcancel := context.WithTimeout(context.WithoutCancel(root), p.cfg.Timeouts.RPC.Duration)
+	defer rcancel()
+	_, _ = p.token.Release(rctx, &pb.ReleaseRequest{UserId: res.user, ReservationId: res.id})
+}
+
+// conversation 确定本轮所属会话，返回交给模型的上下文（prepare 整理好的 req.History，按时间顺序）：
+// 没有指定 conversation_id 时新建一个会话（标题取提问开头），这一轮没保存成功时由 discard 删除。
+// 历史服务不可用时退化为无会话，不阻断对话。
+func (p *pipeline) conversation(c *gin.Context, req chatReq) (conv *chatConv, history []*pb.ChatMessage) {
+	conv = &chatConv{id: req.ConversationID, user: req.UserID}
+	if conv.id == "" {
+		hctx, hcancel := context.WithTimeout(c.Request.Context(), p.cfg.Timeouts.RPC.Duration)
+		defer hcancel()
+		if cv, err := p.history.CreateConversation(hctx, &pb.CreateConversationRequest{
+			UserId: req.UserID, Title: titleFrom(req.Text),
+		}); err == nil {
+			conv.id, conv.created = cv.GetId(), true
+		}
+	}
+	history = make([]*pb.ChatMessage, 0, len(req.History))
+	for _, m := range req.History {
+		history = append(history, &pb.ChatMessage{Role: m.Role, Text: m.Text})
+	}
+	return conv, history
+}
+
+// titleFrom 用提问开头作为新会话标题
+func titleFrom(text string) string {
+	t := []rune(strings.Join(strings.Fields(text), " "))
+	if len(t) > 30 {
+		return string(t[:30]) + "…"
+	}
+	return string(t)
+}
+
+// saveHistory 保存一问一答（非阻塞性，失败也不影响本次响应）
+func (p *pipeline) saveHistory(root context.Context, conv *chatConv, text, reply string) {
+	hctx, hcancel := context.WithTimeout(context.WithoutCancel(root), p.cfg.Timeouts.RPC.Duration)
+	defer hcancel()
+	_, err := p.history.Save(hctx, &pb.SaveRequest{UserId: conv.user, ConversationId: conv.id, Role: "user", Text: text})
+	conv.saved = err == nil
+	_, _ = p.history.Save(hctx, &pb.SaveRequest{UserId: conv.user, ConversationId: conv.id, Role: "assistant", Text: reply})
+}
+
+// discard 删除本轮新建、但没有保存任何消息的会话。handler 里 defer 调用，覆盖 LLM 失败、回复被拦截、
+// 客户端断开等出错路径；保存成功或会话原本就存在时为空操作
+func (p *pipeline) discard(root context.Context, conv *chatConv) {
+	if !conv.created || conv.saved || conv.id == "" {
+		return
+	}
+	hctx, hcancel := context.WithTimeout(context.WithoutCancel(root), p.cfg.Timeouts.RPC.Duration)
+	defer hcancel()
+	_, _ = p.history.DeleteConversation(hctx, &pb.DeleteConversationRequest{UserId: conv.user, ConversationId: conv.id})
+}
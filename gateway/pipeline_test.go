@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/config"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+var testPhoneRe = regexp.MustCompile(`1[3-9]\d{9}`)
+
+// maskingFilter 像 filterserver 一样遮盖手机号（允许回填），编号接着 pii_offsets 往下排
+type maskingFilter struct{ pb.FilterServiceClient }
+
+func (maskingFilter) Filter(_ context.Context, in *pb.FilterRequest, _ ...grpc.CallOption) (*pb.FilterReply, error) {
+	n := in.PiiOffsets["PHONE"]
+	reply := &pb.FilterReply{Allowed: true}
+	reply.Cleaned = testPhoneRe.ReplaceAllStringFunc(in.Text, func(v string) string {
+		n++
+		ph := fmt.Sprintf("[PHONE_%d]", n)
+		reply.Pii = append(reply.Pii, &pb.PiiEntity{Type: "phone", Placeholder: ph, Value: v})
+		return ph
+	})
+	return reply, nil
+}
+
+type allowTokens struct{ pb.TokenServiceClient }
+
+func (allowTokens) Reserve(context.Context, *pb.ReserveRequest, ...grpc.CallOption) (*pb.ReserveReply, error) {
+	return &pb.ReserveReply{Allowed: true, ReservationId: "r1", Remaining: 1000}, nil
+}
+
+// savedHistory 的 List 返回会话里保存的消息（最近在前，已是遮盖后的文本）
+type savedHistory struct {
+	pb.HistoryServiceClient
+	items []*pb.HistoryItem
+}
+
+func (h savedHistory) List(context.Context, *pb.ListRequest, ...grpc.CallOption) (*pb.ListReply, error) {
+	return &pb.ListReply{Items: h.items}, nil
+}
+
+func testPipeline(stored []*pb.HistoryItem) *pipeline {
+	return &pipeline{
+		filter:    maskingFilter{},
+		token:     allowTokens{},
+		history:   savedHistory{items: stored},
+		injection: &injectionPolicy{def: "warn"},
+		cfg:       config.Gateway{Timeouts: config.Timeouts{RPC: config.Duration{Duration: time.Second}}},
+	}
+}
+
+func testContext() *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/chat", nil)
+	return c
+}
+
+// 上下文与提问里各有一个不同的手机号：占位符共用一套编号，回复里上下文的占位符不会被换成提问里的号码
+func TestPreparePIIPlaceholdersDoNotCollide(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		req    chatReq
+		stored []*pb.HistoryItem
+	}{
+		{
+			name: "explicit history",
+			req: chatReq{UserID: "u1", Text: "my new number is 13900139000", History: []chatMsg{
+				{Role: "user", Text: "call me at 13800138000"},
+				{Role: "assistant", Text: "noted"},
+			}},
+		},
+		{
+			name:   "stored history",
+			req:    chatReq{UserID: "u1", Text: "my new number is 13900139000", ConversationID: "c1"},
+			stored: []*pb.HistoryItem{{Role: "assistant", Text: "noted"}, {Role: "user", Text: "call me at [PHONE_1]"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.req
+			fr, _, ok := testPipeline(tc.stored).prepare(testContext(), &req)
+			if !ok {
+				t.Fatal("prepare failed")
+			}
+			if got := req.History[0].Text; got != "call me at [PHONE_1]" {
+				t.Fatalf("history sent to the model = %q, want the phone masked as [PHONE_1]", got)
+			}
+			if got := fr.GetCleaned(); got != "my new number is [PHONE_2]" {
+				t.Fatalf("cleaned text = %q, want numbering continued after the history", got)
+			}
+			reply := newPIIRestorer(fr.GetPii()).restore("old [PHONE_1], new [PHONE_2]")
+			if want := "old [PHONE_1], new 13900139000"; reply != want {
+				t.Fatalf("restored reply = %q, want %q", reply, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+
+	"chatgpt-demo/config"
+	"chatgpt-demo/logging"
+)
+
+// limitRule 是一个令牌桶：容量 limit，每 period 匀速补满
+type limitRule struct {
+	scope  string // user | key | global
+	limit  int64
+	period time.Duration
+}
+
+// parseLimits 解析 gateway.rate_limit.limits（RATE_LIMITS），如 "user:3/1m,key:10/1m,global:60/1m"。
+// 默认 user:3/1m（与 Free 3 RPM 对齐，见 config.Default）；按 key、全局的限制需要显式配置
+func parseLimits(spec string) ([]limitRule, error) {
+	var rules []limitRule
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		scope, rest, _ := strings.Cut(part, ":")
+		n, per, ok := strings.Cut(rest, "/")
+		if !ok {
+			return nil, fmt.Errorf("RATE_LIMITS: %q: want scope:N/period", part)
+		}
+		if scope != "user" && scope != "key" && scope != "global" {
+			return nil, fmt.Errorf("RATE_LIMITS: unknown scope %q (want user|key|global)", scope)
+		}
+		limit, err := strconv.ParseInt(n, 10, 64)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("RATE_LIMITS: %q: bad limit", part)
+		}
+		period, err := time.ParseDuration(per)
+		if err != nil || period < time.Millisecond {
+			return nil, fmt.Errorf("RATE_LIMITS: %q: bad period", part)
+		}
+		rules = append(rules, limitRule{scope: scope, limit: limit, period: period})
+	}
+	return rules, nil
+}
+
+// 所有桶在一个脚本里原子地“先补充、再检查、全部通过才各扣 1”，多个网关实例共享同一组桶。
+// 时间取 Redis 的 TIME，避免各实例时钟不一致。
+//
+// KEYS: 每个桶一个 key；ARGV: 每个桶依次给出 (容量, 周期毫秒)
+// 返回：{allowed, retry_ms, 桶1剩余, 桶1补满毫秒, 桶2剩余, ...}
+var tokenBucketScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local tokens = {}
+local allowed, retry = 1, 0
+for i = 1, #KEYS do
+  local cap = tonumber(ARGV[2*i-1])
+  local rate = cap / tonumber(ARGV[2*i])
+  local b = redis.call('HMGET', KEYS[i], 'tokens', 'ts')
+  local tok, ts = tonumber(b[1]), tonumber(b[2])
+  if tok == nil or ts == nil then
+    tok, ts = cap, now
+  end
+  tok = math.min(cap, tok + math.max(0, now - ts) * rate)
+  if tok < 1 then
+    allowed = 0
+    retry = math.max(retry, math.ceil((1 - tok) / rate))
+  end
+  tokens[i] = tok
+end
+local out = {allowed, retry}
+for i = 1, #KEYS do
+  local cap = tonumber(ARGV[2*i-1])
+  local rate = cap / tonumber(ARGV[2*i])
+  local tok = tokens[i]
+  if allowed == 1 then
+    tok = tok - 1
+  end
+  local full = math.ceil((cap - tok) / rate)
+  redis.call('HSET', KEYS[i], 'tokens', tostring(tok), 'ts', now)
+  redis.call('PEXPIRE', KEYS[i], full + 1000)
+  out[#out+1] = math.floor(tok)
+  out[#out+1] = full
+end
+return out
+`)
+
+// rateLimiter 是基于 Redis 的分布式令牌桶，按用户 / API Key / 全局分别限速。
+//
+// 默认 reject 模式：桶空立即 429 + Retry-After，不占用连接和 goroutine。
+// queue 模式：预计等待不超过 maxWait 的请求在本实例内排队重试，同时排队的请求数不超过 queue 的容量，
+// 排不上或等不到的仍然 429。
+type rateLimiter struct {
+	rdb     *redis.Client
+	rules   []limitRule
+	queue   chan struct{} // reject 模式为 nil
+	maxWait time.Duration
+}
+
+// newRateLimiter 按配置（gateway.rate_limit，模式与队列参数已由 config 校验）创建限流器
+func newRateLimiter(rdb *redis.Client, cfg config.RateLimit) (*rateLimiter, error) {
+	rules, err := parseLimits(cfg.Limits)
+	if err != nil {
+		return nil, err
+	}
+	l := &rateLimiter{rdb: rdb, rules: rules}
+	if cfg.Mode == "queue" {
+		l.queue = make(chan struct{}, cfg.Queue)
+		l.maxWait = cfg.MaxWait.Duration
+	}
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "ratelimit_queue_depth",
+		Help: "Requests currently waiting in the rate limit queue on this instance.",
+	}, func() float64 { return float64(len(l.queue)) }))
+	return l, nil
+}
+
+// bucketKey 返回规则对应的桶；user 为 userID 解析后的身份（租户内为 tenant/user）
+func (r limitRule) bucketKey(user, keyID string) string {
+	switch r.scope {
+	case "user":
+		return "ratelimit:user:" + user
+	case "key":
+		return "ratelimit:key:" + keyID
+	}
+	return "ratelimit:global"
+}
+
+// decision 是一次扣减的结果；tight 为剩余最少的桶，决定响应头
+type decision struct {
+	allowed   bool
+	retry     time.Duration
+	tight     int
+	remaining int64
+	reset     time.Duration
+}
+
+// take 在所有桶上原子地扣减一次
+func (l *rateLimiter) take(ctx context.Context, keys []string, args []any) (decision, error) {
+	ctx, cancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer cancel()
+	out, err := tokenBucketScript.Run(ctx, l.rdb, keys, args...).Int64Slice()
+	if err != nil {
+		return decision{}, err
+	}
+	if len(out) != 2+2*len(l.rules) {
+		return decision{}, fmt.Errorf("unexpected token bucket reply %v", out)
+	}
+	d := decision{allowed: out[0] == 1, retry: time.Duration(out[1]) * time.Millisecond}
+	for i := range l.rules {
+		if out[2+2*i] < out[2+2*d.tight] {
+			d.tight = i
+		}
+	}
+	d.remaining = max(out[2+2*d.tight], 0)
+	d.reset = time.Duration(out[3+2*d.tight]) * time.Millisecond
+	return d, nil
+}
+
+// wait 在排队模式下等桶里补出令牌再重试；排不上队、预计等待超过 maxWait 或客户端断开时返回最后一次的结果
+func (l *rateLimiter) wait(ctx context.Context, keys []string, args []any, d decision) (decision, error) {
+	if l.queue == nil || d.retry > l.maxWait {
+		return d, nil
+	}
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		rateLimitQueue.WithLabelValues("full").Inc()
+		return d, nil
+	}
+	start := time.Now()
+	defer func() {
+		<-l.queue
+		rateLimitWait.Observe(time.Since(start).Seconds())
+		result := "rejected"
+		if d.allowed {
+			result = "allowed"
+		}
+		rateLimitQueue.WithLabelValues(result).Inc()
+	}()
+
+	deadline := time.Now().Add(l.maxWait)
+	for !d.allowed && time.Until(deadline) >= d.retry {
+		t := time.NewTimer(d.retry)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return d, nil
+		case <-t.C:
+		}
+		var err error
+		if d, err = l.take(ctx, keys, args); err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
+
+// allow 扣减一次请求，并写 X-RateLimit-Limit / Remaining / Reset 响应头（取最紧的那个桶）。
+// 被限流时写好 429 + Retry-After 并返回 false。Redis 不可用时放行（fail-open），只记日志。
+func (l *rateLimiter) allow(c *gin.Context, user string) bool {
+	if l == nil || len(l.rules) == 0 {
+		return true
+	}
+	keys := make([]string, len(l.rules))
+	args := make([]any, 0, 2*len(l.rules))
+	for i, r := range l.rules {
+		keys[i] = r.bucketKey(user, apiKey(c).GetId())
+		args = append(args, r.limit, r.period.Milliseconds())
+	}
+
+	ctx := c.Request.Context()
+	d, err := l.take(ctx, keys, args)
+	if err == nil && !d.allowed {
+		d, err = l.wait(ctx, keys, args, d)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("rate limiter unavailable, allowing request", "error", err)
+		return true
+	}
+
+	c.Header("X-RateLimit-Limit", strconv.FormatInt(l.rules[d.tight].limit, 10))
+	c.Header("X-RateLimit-Remaining", strconv.FormatInt(d.remaining, 10))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(ceilSeconds(d.reset), 10))
+
+	if d.allowed {
+		rateLimited.WithLabelValues("allowed").Inc()
+		return true
+	}
+	rateLimited.WithLabelValues("rejected").Inc()
+	retry := ceilSeconds(d.retry)
+	c.Header("Retry-After", strconv.FormatInt(retry, 10))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": "rate_limited", "scope": l.rules[d.tight].scope, "retry_after": retry,
+	})
+	return false
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64((d + time.Second - 1) / time.Second)
+}
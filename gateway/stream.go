@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	pb "chatgpt-demo/chatpb"
+
+	"github.com/gin-gonic/gin"
+)
+
+// chatStream：HTTP(SSE) → (Filter → Token 预占 → LLM 流式 + 边生成边审核 → Token 结算 → Save History)
+//
+// 事件格式：
+//
+//	event: warning data: {"code":"injection_suspected","message":"...","score":0.6,"matches":[...]}（可选，在第一段之前）
+//	event: delta   data: {"text":"..."}
+//	event: usage   data: {"conversation_id":"...","cleaned":"...","pii":[...],"model":"...","usage":{...},"remaining":N,"finish_reason":"stop"}
+//	event: blocked data: {"error":"reply blocked by filter","finish_reason":"content_filter","reason":{...},"ruleset_version":"...","remaining":N}
+//	event: error   data: {"error":"...","detail":"...","status":503}
+func (p *pipeline) chatStream(c *gin.Context) {
+	req, ok := p.bindChat(c)
+	if !ok {
+		return
+	}
+
+	fr, res, ok := p.prepare(c, &req)
+	if !ok {
+		return
+	}
+
+	root := c.Request.Context()
+	defer p.release(root, res) // LLM 出错、推送中断、客户端断开都会退回预占
+
+	conv, history := p.conversation(c, req)
+	defer p.discard(root, conv) // 出错、被拦截时删掉本轮新建的会话
+
+	lctx, lcancel := context.WithTimeout(root, p.cfg.Timeouts.Stream.Duration) // 比非流式宽松，长回答也能完整推完
+	defer lcancel()
+
+	stream, err := p.llm.GenerateStream(lctx, &pb.ChatRequest{
+		UserId: req.UserID, Text: fr.GetCleaned(), History: history, Model: req.Model,
+	})
+	if err != nil {
+		writeLLMError(c, err)
+		return
+	}
+
+	// 先收第一帧：上游错误（额度/限速）一般在这里暴露，此时还能返回正常的 HTTP 状态码
+	first, err := stream.Recv()
+	if err != nil && err != io.EOF {
+		writeLLMError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭反向代理缓冲
+	c.Header("X-Conversation-ID", conv.id)
+	c.Status(http.StatusOK)
+	for _, w := range injectionWarnings(c) {
+		c.SSEvent("warning", w)
+	}
+
+	// 增量先过审核（moderator，按段审核、可中途拦截），再按策略把占位符换回原值（restorer）后推送；
+	// 保存历史用审核后的文本（含占位符）
+	var last *pb.ChatChunk
+	moderator := p.newReplyModerator(root, req.UserID)
+	restorer := newPIIRestorer(fr.GetPii())
+	push := func(s string) {
+		if out := restorer.write(s); out != "" {
+			c.SSEvent("delta", gin.H{"text": out})
+			c.Writer.Flush()
+		}
+	}
+	for chunk := first; chunk != nil; {
+		if chunk.GetDone() {
+			last = chunk
+		} else if d := chunk.GetDelta(); d != "" {
+			out, err := moderator.write(d)
+			if err != nil || moderator.blocked != nil {
+				p.cutStream(c, res, moderator.blocked, err)
+				return
+			}
+			push(out)
+		}
+
+		chunk, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// 响应头已发出，只能通过事件告知错误；status 为对应的 HTTP 状态码
+			e := classifyLLMError(err)
+			llmErrors.WithLabelValues(e.body["error"].(string)).Inc()
+			e.body["status"] = e.status
+			c.SSEvent("error", e.body)
+			c.Writer.Flush()
+			return
+		}
+	}
+
+	out, err := moderator.flush()
+	if err != nil || moderator.blocked != nil {
+		p.cutStream(c, res, moderator.blocked, err)
+		return
+	}
+	push(out)
+	if out := restorer.flush(); out != "" {
+		c.SSEvent("delta", gin.H{"text": out})
+	}
+
+	// 结算预占 + 保存历史（与 /chat 一致）
+	finalRemaining := p.commit(root, res, last.GetTotalTokens())
+	p.saveHistory(root, conv, storedText(req.Text, fr), moderator.text())
+
+	c.SSEvent("usage", gin.H{
+		"conversation_id": conv.id,
+		"cleaned":         fr.GetCleaned(),
+		"pii":             piiSummary(fr.GetPii()),
+		"model":           last.GetModel(),
+		"usage": gin.H{
+			"prompt_tokens":     last.GetPromptTokens(),
+			"completion_tokens": last.GetCompletionTokens(),
+			"total_tokens":      last.GetTotalTokens(),
+		},
+		"remaining":     finalRemaining,
+		"finish_reason": "stop",
+	})
+	c.Writer.Flush()
+}
+
+// cutStream 在审核拦截或审核失败时中断推送：拦截发 blocked 事件并按预占额计费（已生成的部分无从得知用量），
+// 审核失败发 error 事件并退回预占。已推送的内容无法撤回，客户端收到 blocked 后应丢弃本条回复。
+func (p *pipeline) cutStream(c *gin.Context, res *reservation, blocked *pb.FilterReply, err error) {
+	if err != nil {
+		c.SSEvent("error", gin.H{"error": "filter failed", "detail": err.Error(), "status": http.StatusInternalServerError})
+		c.Writer.Flush()
+		return
+	}
+	remaining := p.commit(c.Request.Context(), res, 0)
+	c.SSEvent("blocked", gin.H{
+		"error":           "reply blocked by filter",
+		"finish_reason":   "content_filter",
+		"reason":          blockReason(c, blocked, pb.FilterDirection_OUTPUT),
+		"ruleset_version": blocked.GetRulesetVersion(),
+		"remaining":       remaining,
+	})
+	c.Writer.Flush()
+}
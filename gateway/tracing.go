@@ -0,0 +1,25 @@
+package main
+
+import (
+	"chatgpt-demo/tracing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// traced 决定哪些请求开 span：健康检查、指标抓取与静态页不追踪
+func traced(c *gin.Context) bool {
+	switch c.FullPath() {
+	case "/health", "/metrics", "/":
+		return false
+	}
+	return true
+}
+
+// traceHeader 把本次请求的 trace_id 写进 X-Trace-ID 响应头，便于用户反馈问题时按 trace 排查。
+// 须挂在 otelgin 中间件之后（span 由它创建）。
+func traceHeader(c *gin.Context) {
+	if id := tracing.TraceID(c.Request.Context()); id != "" {
+		c.Header("X-Trace-ID", id)
+	}
+	c.Next()
+}
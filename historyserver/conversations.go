@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 标题最多保留的字符数
+const maxTitle = 100
+
+var errConvNotFound = status.Error(codes.NotFound, "conversation not found")
+
+// convID 校验并解析会话 ID（对外是不透明字符串，库里是自增主键）
+func convID(id string) (int64, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "bad conversation_id %q", id)
+	}
+	return n, nil
+}
+
+// ownConversation 校验会话归属
+func (s *server) ownConversation(ctx context.Context, user string, conv int64) error {
+	var one int
+	err := s.db.QueryRowContext(ctx,
+		"SELECT 1 FROM conversations WHERE id=? AND user_id=?", conv, user).Scan(&one)
+	if err == sql.ErrNoRows {
+		return errConvNotFound
+	}
+	return err
+}
+
+func normTitle(t string) string {
+	t = strings.Join(strings.Fields(t), " ")
+	if r := []rune(t); len(r) > maxTitle {
+		t = string(r[:maxTitle])
+	}
+	return t
+}
+
+func (s *server) CreateConversation(ctx context.Context, in *pb.CreateConversationRequest) (*pb.Conversation, error) {
+	title := normTitle(in.Title)
+	res, err := s.db.ExecContext(ctx,
+		"INSERT INTO conversations(user_id, title) VALUES(?,?)", in.UserId, title)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	return &pb.Conversation{Id: strconv.FormatInt(id, 10), Title: title, CreatedAt: now, UpdatedAt: now}, nil
+}
+
+func (s *server) ListConversations(ctx context.Context, in *pb.ListConversationsRequest) (*pb.ListConversationsReply, error) {
+	limit := in.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, title, UNIX_TIMESTAMP(created_at), UNIX_TIMESTAMP(updated_at) FROM conversations WHERE user_id=? ORDER BY updated_at DESC, id DESC LIMIT ?",
+		in.UserId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var convs []*pb.Conversation
+	for rows.Next() {
+		var id int64
+		c := &pb.Conversation{}
+		if err := rows.Scan(&id, &c.Title, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		c.Id = strconv.FormatInt(id, 10)
+		convs = append(convs, c)
+	}
+	return &pb.ListConversationsReply{Conversations: convs}, rows.Err()
+}
+
+func (s *server) RenameConversation(ctx context.Context, in *pb.RenameConversationRequest) (*pb.Conversation, error) {
+	conv, err := convID(in.ConversationId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.db.ExecContext(ctx,
+		"UPDATE conversations SET title=? WHERE id=? AND user_id=?",
+		normTitle(in.Title), conv, in.UserId); err != nil {
+		return nil, err
+	}
+
+	c := &pb.Conversation{Id: in.ConversationId}
+	err = s.db.QueryRowContext(ctx,
+		"SELECT title, UNIX_TIMESTAMP(created_at), UNIX_TIMESTAMP(updated_at) FROM conversations WHERE id=? AND user_id=?",
+		conv, in.UserId).Scan(&c.Title, &c.CreatedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errConvNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DeleteConversation 删除会话及其全部消息，并清掉相关缓存
+func (s *server) DeleteConversation(ctx context.Context, in *pb.DeleteConversationRequest) (*pb.DeleteConversationReply, error) {
+	conv, err := convID(in.ConversationId)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM conversations WHERE id=? AND user_id=?", conv, in.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, errConvNotFound
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM chat_history WHERE conversation_id=? AND user_id=?", conv, in.UserId); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// 用户维度的缓存里也可能有这些消息，一并失效（Miss 时回源 MySQL）
+	if s.rdb != nil {
+		_ = s.rdb.Del(ctx, ckey(in.UserId, in.ConversationId), hkey(in.UserId)).Err()
+	}
+	return &pb.DeleteConversationReply{Ok: true}, nil
+}
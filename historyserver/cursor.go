@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	pb "chatgpt-demo/chatpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 单页上限
+const maxPage = 100
+
+// encodeCursor 把消息位置编码成不透明游标："v1:{id}:{created_at}" 的 base64url
+func encodeCursor(it *pb.HistoryItem) string {
+	return base64.RawURLEncoding.EncodeToString(
+		fmt.Appendf(nil, "v1:%s:%d", it.GetId(), it.GetCreatedAt()))
+}
+
+// decodeCursor 取出游标里的消息 id（翻页按自增 id 定位，created_at 仅供排查）
+func decodeCursor(c string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(c)
+	if err == nil {
+		parts := strings.Split(string(raw), ":")
+		if len(parts) == 3 && parts[0] == "v1" {
+			if id, err := strconv.ParseInt(parts[1], 10, 64); err == nil && id > 0 {
+				return id, nil
+			}
+		}
+	}
+	return 0, status.Errorf(codes.InvalidArgument, "bad cursor %q", c)
+}
+
+// nextCursor 满页时返回最后一条的游标，否则说明已经到头
+func nextCursor(items []*pb.HistoryItem, limit int64) string {
+	if int64(len(items)) < limit || len(items) == 0 {
+		return ""
+	}
+	return encodeCursor(items[len(items)-1])
+}
@@ -4,50 +4,87 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"log"
+	"log/slog"
 	"net"
-	"os"
+	"strconv"
 	"time"
 
 	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/config"
+	"chatgpt-demo/logging"
+	"chatgpt-demo/metrics"
+	"chatgpt-demo/tracing"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 )
 
 type server struct {
 	pb.UnimplementedHistoryServiceServer
-	db  *sql.DB
-	rdb *redis.Client
+	db     *sql.DB
+	rdb    *redis.Client
+	cacheN int // 每个会话缓存最近 N 条
 }
 
 func hkey(user string) string { return "history:" + user }
 
-const cacheN = 40
+// 单个会话的最近 N 条
+func ckey(user, conv string) string { return "history:" + user + ":" + conv }
 
 type item struct {
-	Role string `json:"role"`
-	Text string `json:"text"`
+	ID        int64  `json:"id"`
+	Role      string `json:"role"`
+	Text      string `json:"text"`
+	CreatedAt int64  `json:"created_at"`
 }
 
 func (s *server) Save(ctx context.Context, in *pb.SaveRequest) (*pb.SaveReply, error) {
 	// 1) 持久化到 MySQL
-	_, err := s.db.ExecContext(ctx,
-		"INSERT INTO chat_history(user_id, role, text) VALUES(?,?,?)",
-		in.UserId, in.Role, in.Text)
-	if err != nil {
-		return &pb.SaveReply{Ok: false}, err
+	var res sql.Result
+	if in.ConversationId == "" {
+		var err error
+		res, err = s.db.ExecContext(ctx,
+			"INSERT INTO chat_history(user_id, role, text) VALUES(?,?,?)",
+			in.UserId, in.Role, in.Text)
+		if err != nil {
+			return &pb.SaveReply{Ok: false}, err
+		}
+	} else {
+		conv, err := convID(in.ConversationId)
+		if err != nil {
+			return &pb.SaveReply{Ok: false}, err
+		}
+		// 只能写入自己的会话：会话不属于该用户时插入 0 行
+		res, err = s.db.ExecContext(ctx,
+			"INSERT INTO chat_history(user_id, conversation_id, role, text) SELECT user_id, id, ?, ? FROM conversations WHERE id=? AND user_id=?",
+			in.Role, in.Text, conv, in.UserId)
+		if err != nil {
+			return &pb.SaveReply{Ok: false}, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return &pb.SaveReply{Ok: false}, errConvNotFound
+		}
+		_, _ = s.db.ExecContext(ctx, "UPDATE conversations SET updated_at=CURRENT_TIMESTAMP WHERE id=?", conv)
 	}
 
-	// 2) 写入 Redis 最近 N 条（LPUSH + LTRIM）
+	// 2) 写入 Redis 最近 N 条（LPUSH + LTRIM）：用户维度 + 会话维度
+	// 带上 id/created_at，首页命中缓存时也能给出翻页游标
 	if s.rdb != nil {
-		b, _ := json.Marshal(item{Role: in.Role, Text: in.Text})
+		id, _ := res.LastInsertId()
+		b, _ := json.Marshal(item{ID: id, Role: in.Role, Text: in.Text, CreatedAt: time.Now().Unix()})
+		keys := []string{hkey(in.UserId)}
+		if in.ConversationId != "" {
+			keys = append(keys, ckey(in.UserId, in.ConversationId))
+		}
 		pipe := s.rdb.TxPipeline()
-		pipe.LPush(ctx, hkey(in.UserId), b)
-		pipe.LTrim(ctx, hkey(in.UserId), 0, cacheN-1)
-		pipe.Expire(ctx, hkey(in.UserId), 24*time.Hour)
+		for _, k := range keys {
+			pipe.LPush(ctx, k, b)
+			pipe.LTrim(ctx, k, 0, int64(s.cacheN-1))
+			pipe.Expire(ctx, k, 24*time.Hour)
+		}
 		_, _ = pipe.Exec(ctx)
 	}
 
@@ -59,26 +96,58 @@ func (s *server) List(ctx context.Context, in *pb.ListRequest) (*pb.ListReply, e
 	if limit <= 0 {
 		limit = 20
 	}
+	if limit > maxPage {
+		limit = maxPage
+	}
 
-	// 1) 先查 Redis
-	if s.rdb != nil {
-		raws, err := s.rdb.LRange(ctx, hkey(in.UserId), 0, limit-1).Result()
-		if err == nil && len(raws) > 0 {
-			items := make([]*pb.HistoryItem, 0, len(raws))
-			for _, r := range raws {
-				var it item
-				if json.Unmarshal([]byte(r), &it) == nil {
-					items = append(items, &pb.HistoryItem{Role: it.Role, Text: it.Text})
-				}
-			}
-			return &pb.ListReply{Items: items}, nil
+	var conv int64
+	key := hkey(in.UserId)
+	where := "user_id=?"
+	args := []any{in.UserId}
+	if in.ConversationId != "" {
+		var err error
+		if conv, err = convID(in.ConversationId); err != nil {
+			return nil, err
 		}
+		key = ckey(in.UserId, in.ConversationId)
+		where += " AND conversation_id=?"
+		args = append(args, conv)
+	}
+
+	// 1) 先查 Redis：只服务首页（无游标、往更早翻），且缓存里够一整页时才命中
+	if s.rdb != nil && in.Cursor == "" && in.Direction == pb.ListDirection_OLDER && limit <= int64(s.cacheN) {
+		if items, ok := s.cachedPage(ctx, key, limit); ok {
+			return &pb.ListReply{Items: items, NextCursor: nextCursor(items, limit)}, nil
+		}
+	}
+
+	// 2) Miss / 翻页：查 MySQL；会话不存在或不属于该用户时返回 NotFound
+	if conv != 0 {
+		if err := s.ownConversation(ctx, in.UserId, conv); err != nil {
+			return nil, err
+		}
+	}
+	order := "DESC"
+	if in.Cursor != "" {
+		id, err := decodeCursor(in.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if in.Direction == pb.ListDirection_NEWER {
+			where += " AND id>?"
+		} else {
+			where += " AND id<?"
+		}
+		args = append(args, id)
+	}
+	if in.Direction == pb.ListDirection_NEWER {
+		order = "ASC"
 	}
+	args = append(args, limit)
 
-	// 2) Miss：查 MySQL（倒序取最近）
 	rows, err := s.db.QueryContext(ctx,
-		"SELECT role, text FROM chat_history WHERE user_id=? ORDER BY id DESC LIMIT ?",
-		in.UserId, limit)
+		"SELECT id, role, text, UNIX_TIMESTAMP(created_at) FROM chat_history WHERE "+where+" ORDER BY id "+order+" LIMIT ?",
+		args...)
 	if err != nil {
 		return nil, err
 	}
@@ -86,47 +155,75 @@ func (s *server) List(ctx context.Context, in *pb.ListRequest) (*pb.ListReply, e
 
 	var items []*pb.HistoryItem
 	for rows.Next() {
+		var id, at int64
 		var role, text string
-		_ = rows.Scan(&role, &text)
-		items = append(items, &pb.HistoryItem{Role: role, Text: text})
+		if err := rows.Scan(&id, &role, &text, &at); err != nil {
+			return nil, err
+		}
+		items = append(items, &pb.HistoryItem{Id: strconv.FormatInt(id, 10), Role: role, Text: text, CreatedAt: at})
+	}
+	// 读到一半出错时不能返回截断的一页和有效游标
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
-	return &pb.ListReply{Items: items}, nil
+	return &pb.ListReply{Items: items, NextCursor: nextCursor(items, limit)}, nil
+}
+
+// cachedPage 从 Redis 取最近 limit 条；不足一页或有旧格式（无 id）的条目时视为 Miss，回源 MySQL
+func (s *server) cachedPage(ctx context.Context, key string, limit int64) ([]*pb.HistoryItem, bool) {
+	raws, err := s.rdb.LRange(ctx, key, 0, limit-1).Result()
+	if err != nil || int64(len(raws)) < limit {
+		return nil, false
+	}
+	items := make([]*pb.HistoryItem, 0, len(raws))
+	for _, r := range raws {
+		var it item
+		if json.Unmarshal([]byte(r), &it) != nil || it.ID == 0 {
+			return nil, false
+		}
+		items = append(items, &pb.HistoryItem{
+			Id: strconv.FormatInt(it.ID, 10), Role: it.Role, Text: it.Text, CreatedAt: it.CreatedAt,
+		})
+	}
+	return items, true
 }
 
 func main() {
-	// MySQL 连接
-	dsn := os.Getenv("MYSQL_DSN")
-	if dsn == "" {
-		user := getenv("MYSQL_USER", "root")
-		pass := getenv("MYSQL_PASSWORD", "root")
-		host := getenv("MYSQL_ADDR", "localhost:3306")
-		dsn = fmt.Sprintf("%s:%s@tcp(%s)/chatdb?parseTime=true&charset=utf8mb4,utf8", user, pass, host)
-	}
-	db, err := sql.Open("mysql", dsn)
+	logging.Init("historyserver")
+	cfg := config.MustLoad("historyserver")
+
+	shutdown, err := tracing.Init(context.Background(), "historyserver")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
+	db, err := tracing.OpenMySQL(cfg.MySQL.DataSource())
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	// Redis（可选，但推荐）
-	redisAddr := getenv("REDIS_ADDR", "localhost:6379")
-	rdb := redis.NewClient(&redis.Options{Addr: redisAddr})
+	rdb := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr})
+	tracing.InstrumentRedis(rdb)
+
+	metrics.DBPool(db, "chatdb")
+	metrics.RedisPool(rdb)
+	metrics.Serve(cfg.History.MetricsAddr)
 
-	lis, err := net.Listen("tcp", ":50054")
+	lis, err := net.Listen("tcp", cfg.History.Addr)
 	if err != nil {
 		log.Fatal(err)
 	}
-	s := grpc.NewServer()
-	pb.RegisterHistoryServiceServer(s, &server{db: db, rdb: rdb})
-
-	log.Println("History service @ :50054, mysql =", dsn, "redis =", redisAddr)
+	s := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(logging.UnaryServerInterceptor, metrics.UnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(logging.StreamServerInterceptor, metrics.StreamServerInterceptor),
+	)
+	pb.RegisterHistoryServiceServer(s, &server{db: db, rdb: rdb, cacheN: cfg.History.CacheN})
+
+	slog.Info("history service listening", "addr", cfg.History.Addr, "redis", cfg.Redis.Addr)
 	if err := s.Serve(lis); err != nil {
 		log.Fatal(err)
 	}
 }
-
-func getenv(k, d string) string {
-	if v := os.Getenv(k); v != "" {
-		return v
-	}
-	return d
-}
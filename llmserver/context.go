@@ -0,0 +1,43 @@
+package main
+
+import (
+	"unicode/utf8"
+
+	pb "chatgpt-demo/chatpb"
+)
+
+// estimateTokens 粗略估算 token 数：ASCII 约 4 字符/token，中文等约 1 字符/token，
+// 再加每条消息的固定开销。只用于裁剪上下文，不参与计费。
+func estimateTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return ascii/4 + other + 4
+}
+
+// buildMessages 按时间顺序拼装历史轮次 + 本轮提问。
+// 超出预算时从最早的轮次开始丢弃，本轮提问总是保留。
+func (s *server) buildMessages(in *pb.ChatRequest) []*pb.ChatMessage {
+	hist := in.GetHistory()
+	budget := s.budget - estimateTokens(in.GetText())
+
+	// 从最近一条往前累加，直到超出预算
+	start := len(hist)
+	for i := len(hist) - 1; i >= 0; i-- {
+		cost := estimateTokens(hist[i].GetText())
+		if cost > budget {
+			break
+		}
+		budget -= cost
+		start = i
+	}
+
+	msgs := make([]*pb.ChatMessage, 0, len(hist)-start+1)
+	msgs = append(msgs, hist[start:]...)
+	return append(msgs, &pb.ChatMessage{Role: "user", Text: in.GetText()})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// toStatus 把后端错误翻译成 gRPC 状态码 + errdetails，网关据此映射 HTTP 状态码与 Retry-After：
+//
+//	额度不足            → ResourceExhausted + QuotaFailure
+//	限速 429            → ResourceExhausted + RetryInfo
+//	5xx                 → Unavailable + RetryInfo
+//	超时                → DeadlineExceeded
+//	请求参数 400/422    → InvalidArgument
+//	鉴权/模型不可用      → FailedPrecondition（服务端配置问题）
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err // 已经是 gRPC 状态（如模型不在白名单）
+	}
+	var pe *providerError
+	switch {
+	case errors.As(err, &pe):
+		return providerStatus(pe)
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func providerStatus(pe *providerError) error {
+	switch {
+	case pe.Code == "insufficient_quota":
+		return withDetails(status.New(codes.ResourceExhausted, pe.Error()), &errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{{Subject: "llm:billing", Description: pe.Msg}},
+		})
+	case pe.Status == http.StatusTooManyRequests:
+		return withRetry(status.New(codes.ResourceExhausted, pe.Error()), pe.RetryAfter)
+	case pe.Status >= 500:
+		return withRetry(status.New(codes.Unavailable, pe.Error()), pe.RetryAfter)
+	case pe.Status == http.StatusBadRequest || pe.Status == http.StatusUnprocessableEntity:
+		return status.Error(codes.InvalidArgument, pe.Error())
+	case pe.Status == http.StatusUnauthorized || pe.Status == http.StatusForbidden || pe.Status == http.StatusNotFound:
+		return status.Error(codes.FailedPrecondition, pe.Error())
+	}
+	return status.Error(codes.Internal, pe.Error())
+}
+
+func withRetry(st *status.Status, after time.Duration) error {
+	if after <= 0 {
+		return st.Err()
+	}
+	return withDetails(st, &errdetails.RetryInfo{RetryDelay: durationpb.New(after)})
+}
+
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	if ds, err := st.WithDetails(details...); err == nil {
+		return ds.Err()
+	}
+	return st.Err()
+}
+
+// parseRetryAfter 解析上游的 retry-after-ms / Retry-After（秒或 HTTP 日期）
+func parseRetryAfter(h http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(h.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	v := h.Get("Retry-After")
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -2,79 +2,102 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"log/slog"
 	"net"
-	"os"
 
 	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/config"
+	"chatgpt-demo/logging"
+	"chatgpt-demo/metrics"
+	"chatgpt-demo/tracing"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
-
-	openai "github.com/openai/openai-go/v3"
-	"github.com/openai/openai-go/v3/option"
 )
 
 type server struct {
 	pb.UnimplementedLLMServiceServer
-	client openai.Client
-	model  string
+	provider provider
+	routes   []route // 模型白名单 + 回退顺序
+	budget   int     // 上下文 token 预算（含本轮提问），llm.context_budget
 }
 
-func newServer() *server {
-	key := os.Getenv("OPENAI_API_KEY")
-	if key == "" {
-		log.Fatal("OPENAI_API_KEY is empty")
-	}
-	model := os.Getenv("OPENAI_MODEL")
-	if model == "" {
-		model = "gpt-4o-mini"
-	}
-	return &server{
-		client: openai.NewClient(option.WithAPIKey(key)),
-		model:  model,
+func newServer(p provider, cfg config.LLM) (*server, error) {
+	routes, err := parseRoutes(cfg.Models, cfg.Model)
+	if err != nil {
+		return nil, err
 	}
+	return &server{provider: p, routes: routes, budget: cfg.ContextBudget}, nil
 }
 
 func (s *server) Generate(ctx context.Context, in *pb.ChatRequest) (*pb.ChatResponse, error) {
-	resp, err := s.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.UserMessage(in.Text),
-		},
-		Model: openai.ChatModel(s.model),
-	})
+	c, model, err := s.complete(ctx, in)
 	if err != nil {
-		return nil, err
+		return nil, toStatus(err)
 	}
+	recordUsage(model, c)
 
-	reply := ""
-	if len(resp.Choices) > 0 {
-		reply = resp.Choices[0].Message.Content
-	}
+	return &pb.ChatResponse{
+		Reply:            c.Reply,
+		PromptTokens:     c.PromptTokens,
+		CompletionTokens: c.CompletionTokens,
+		TotalTokens:      c.TotalTokens,
+		Model:            model,
+	}, nil
+}
 
-	// 安全地读取 usage
-	var pt, ct, tt int32
-	if resp.Usage.PromptTokens != 0 || resp.Usage.CompletionTokens != 0 || resp.Usage.TotalTokens != 0 {
-		pt = int32(resp.Usage.PromptTokens)
-		ct = int32(resp.Usage.CompletionTokens)
-		tt = int32(resp.Usage.TotalTokens)
+// GenerateStream 逐段转发后端的增量文本，最后发送 done=true 的结束帧（带真实用量）
+func (s *server) GenerateStream(in *pb.ChatRequest, out pb.LLMService_GenerateStreamServer) error {
+	c, model, err := s.stream(out.Context(), in, func(delta string) error {
+		return out.Send(&pb.ChatChunk{Delta: delta})
+	})
+	if err != nil {
+		return toStatus(err)
 	}
+	recordUsage(model, c)
 
-	return &pb.ChatResponse{
-		Reply:            reply,
-		PromptTokens:     pt,
-		CompletionTokens: ct,
-		TotalTokens:      tt,
-	}, nil
+	return out.Send(&pb.ChatChunk{
+		Done:             true,
+		PromptTokens:     c.PromptTokens,
+		CompletionTokens: c.CompletionTokens,
+		TotalTokens:      c.TotalTokens,
+		Model:            model,
+	})
 }
 
 func main() {
-	lis, err := net.Listen("tcp", ":50055")
+	logging.Init("llmserver")
+	cfg := config.MustLoad("llmserver")
+	shutdown, err := tracing.Init(context.Background(), "llmserver")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
+	p, err := newProvider(cfg.LLM)
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv, err := newServer(p, cfg.LLM)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	metrics.Serve(cfg.LLM.MetricsAddr)
+
+	lis, err := net.Listen("tcp", cfg.LLM.Addr)
 	if err != nil {
 		log.Fatal(err)
 	}
-	s := grpc.NewServer()
-	pb.RegisterLLMServiceServer(s, newServer())
-	log.Println("LLM (OpenAI) listening :50055")
+	s := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(logging.UnaryServerInterceptor, metrics.UnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(logging.StreamServerInterceptor, metrics.StreamServerInterceptor),
+	)
+	pb.RegisterLLMServiceServer(s, srv)
+	slog.Info("llm service listening", "addr", cfg.LLM.Addr, "provider", cfg.LLM.Provider, "models", fmt.Sprint(srv.routes))
 	if err := s.Serve(lis); err != nil {
 		log.Fatal(err)
 	}
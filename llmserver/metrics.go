@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	llmTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_tokens_total",
+		Help: "Tokens spent on successful generations, by model and type (prompt|completion).",
+	}, []string{"model", "type"})
+	llmFallbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_fallbacks_total",
+		Help: "Attempts that failed and fell back to the next model, by the failing model.",
+	}, []string{"model"})
+)
+
+// recordUsage 按实际作答的模型累计 token 用量
+func recordUsage(model string, c *completion) {
+	llmTokens.WithLabelValues(model, "prompt").Add(float64(c.PromptTokens))
+	llmTokens.WithLabelValues(model, "completion").Add(float64(c.CompletionTokens))
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/config"
+)
+
+// mockProvider 是离线、确定性的后端，用于本地开发与端到端测试：
+//
+//	MOCK_REPLY    固定回复；为空时回显 "echo: <本轮提问>"
+//	MOCK_LATENCY  每次生成的总耗时（如 300ms），流式时均摊到每一段
+//	MOCK_ERROR    每次都返回该错误：insufficient_quota | rate_limit | unavailable
+//
+// 也可以在提问里带上 [mock:insufficient_quota] 等标记，只让这一次请求出错；
+// [mock:rate_limit@gpt-4o-mini] 只让指定模型出错，用于验证回退链。
+type mockProvider struct {
+	reply   string
+	latency time.Duration
+	err     string
+}
+
+func newMockProvider(cfg config.Mock) *mockProvider {
+	return &mockProvider{reply: cfg.Reply, latency: cfg.Latency.Duration, err: cfg.Error}
+}
+
+// mockErrors 模拟 OpenAI 的几类常见错误
+var mockErrors = map[string]*providerError{
+	"insufficient_quota": {Status: http.StatusTooManyRequests, Code: "insufficient_quota", Msg: "You exceeded your current quota"},
+	"rate_limit":         {Status: http.StatusTooManyRequests, Code: "rate_limit_exceeded", Msg: "Rate limit reached", RetryAfter: 20 * time.Second},
+	"unavailable":        {Status: http.StatusServiceUnavailable, Code: "server_error", Msg: "The server is overloaded", RetryAfter: 5 * time.Second},
+}
+
+// injected 返回本次要注入的错误（环境变量优先，其次是提问里的 [mock:xxx] / [mock:xxx@model] 标记）
+func (p *mockProvider) injected(model, prompt string) error {
+	if e, ok := mockErrors[p.err]; ok {
+		return e
+	}
+	for name, e := range mockErrors {
+		if strings.Contains(prompt, "[mock:"+name+"]") || strings.Contains(prompt, "[mock:"+name+"@"+model+"]") {
+			return e
+		}
+	}
+	return nil
+}
+
+func (p *mockProvider) answer(model string, msgs []*pb.ChatMessage) (*completion, error) {
+	prompt := ""
+	if len(msgs) > 0 {
+		prompt = msgs[len(msgs)-1].GetText()
+	}
+	if err := p.injected(model, prompt); err != nil {
+		return nil, err
+	}
+
+	reply := p.reply
+	if reply == "" {
+		reply = "echo: " + prompt
+	}
+
+	// 合成用量：与上下文裁剪使用同一套估算，结果确定
+	var pt int32
+	for _, m := range msgs {
+		pt += int32(estimateTokens(m.GetText()))
+	}
+	ct := int32(estimateTokens(reply))
+	return &completion{Reply: reply, PromptTokens: pt, CompletionTokens: ct, TotalTokens: pt + ct}, nil
+}
+
+func (p *mockProvider) Complete(ctx context.Context, model string, msgs []*pb.ChatMessage) (*completion, error) {
+	if err := sleep(ctx, p.latency); err != nil {
+		return nil, err
+	}
+	return p.answer(model, msgs)
+}
+
+func (p *mockProvider) Stream(ctx context.Context, model string, msgs []*pb.ChatMessage, onDelta func(string) error) (*completion, error) {
+	c, err := p.answer(model, msgs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按空白切段推送（保留分隔符，拼起来与完整回复一致）
+	parts := strings.SplitAfter(c.Reply, " ")
+	step := p.latency / time.Duration(len(parts))
+	for _, part := range parts {
+		if err := sleep(ctx, step); err != nil {
+			return nil, err
+		}
+		if part == "" {
+			continue
+		}
+		if err := onDelta(part); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// sleep 等待 d，期间响应 ctx 取消（模拟超时）
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+)
+
+func userMsg(text string) []*pb.ChatMessage {
+	return []*pb.ChatMessage{{Role: "user", Text: text}}
+}
+
+func TestMockEchoAndUsage(t *testing.T) {
+	p := &mockProvider{}
+	c, err := p.Complete(context.Background(), "m1", userMsg("hello there"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Reply != "echo: hello there" {
+		t.Fatalf("reply = %q", c.Reply)
+	}
+	if c.PromptTokens <= 0 || c.CompletionTokens <= 0 || c.TotalTokens != c.PromptTokens+c.CompletionTokens {
+		t.Fatalf("usage = %+v", c)
+	}
+}
+
+func TestMockLatency(t *testing.T) {
+	p := &mockProvider{reply: "a b c d", latency: 80 * time.Millisecond}
+
+	start := time.Now()
+	if _, err := p.Complete(context.Background(), "m1", userMsg("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 80*time.Millisecond {
+		t.Fatalf("Complete took %s, want >= 80ms", d)
+	}
+
+	// 流式：耗时均摊到每一段，拼起来与完整回复一致
+	var parts []string
+	start = time.Now()
+	c, err := p.Stream(context.Background(), "m1", userMsg("hi"), func(d string) error {
+		parts = append(parts, d)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 80*time.Millisecond {
+		t.Fatalf("Stream took %s, want >= 80ms", d)
+	}
+	if len(parts) != 4 || strings.Join(parts, "") != c.Reply {
+		t.Fatalf("deltas = %q, reply = %q", parts, c.Reply)
+	}
+
+	// 调用方的 deadline 比模拟耗时短：返回 DeadlineExceeded
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Complete(ctx, "m1", userMsg("hi")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestMockErrorInjection(t *testing.T) {
+	tests := []struct {
+		name     string
+		p        *mockProvider
+		model    string
+		prompt   string
+		wantCode string // 空表示不出错
+	}{
+		{"configured", &mockProvider{err: "unavailable"}, "m1", "hi", "server_error"},
+		{"tag", &mockProvider{}, "m1", "hi [mock:insufficient_quota]", "insufficient_quota"},
+		{"tag for this model", &mockProvider{}, "m1", "hi [mock:rate_limit@m1]", "rate_limit_exceeded"},
+		{"tag for another model", &mockProvider{}, "m2", "hi [mock:rate_limit@m1]", ""},
+		{"unknown tag", &mockProvider{}, "m1", "hi [mock:nope]", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, stream := range []bool{false, true} {
+				var err error
+				if stream {
+					_, err = tt.p.Stream(context.Background(), tt.model, userMsg(tt.prompt), func(string) error { return nil })
+				} else {
+					_, err = tt.p.Complete(context.Background(), tt.model, userMsg(tt.prompt))
+				}
+				var pe *providerError
+				switch {
+				case tt.wantCode == "" && err != nil:
+					t.Fatalf("stream=%v: err = %v, want nil", stream, err)
+				case tt.wantCode != "" && (!errors.As(err, &pe) || pe.Code != tt.wantCode):
+					t.Fatalf("stream=%v: err = %v, want code %s", stream, err, tt.wantCode)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	pb "chatgpt-demo/chatpb"
+
+	openai "github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// openaiProvider 直连 OpenAI Chat Completions
+type openaiProvider struct {
+	client openai.Client
+}
+
+func newOpenAIProvider(key string) (*openaiProvider, error) {
+	if key == "" {
+		return nil, errors.New("OPENAI_API_KEY is empty (set LLM_PROVIDER=mock to run offline)")
+	}
+	// HTTP 请求走 otelhttp，每次调用 OpenAI 都有一个 client span（含重试）
+	hc := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	return &openaiProvider{client: openai.NewClient(option.WithAPIKey(key), option.WithHTTPClient(hc))}, nil
+}
+
+func toOpenAIMessages(msgs []*pb.ChatMessage) []openai.ChatCompletionMessageParamUnion {
+	out := make([]openai.ChatCompletionMessageParamUnion, 0, len(msgs))
+	for _, m := range msgs {
+		switch m.GetRole() {
+		case "assistant":
+			out = append(out, openai.AssistantMessage(m.GetText()))
+		default: // 上下文只有 user / assistant，调用方不能借 history 塞入 system 指令
+			out = append(out, openai.UserMessage(m.GetText()))
+		}
+	}
+	return out
+}
+
+// fromOpenAIError 把 OpenAI 的 HTTP 错误转成 providerError，便于路由层判断是否回退
+func fromOpenAIError(err error) error {
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		pe := &providerError{Status: apiErr.StatusCode, Code: apiErr.Code, Msg: apiErr.Message}
+		if apiErr.Response != nil {
+			pe.RetryAfter = parseRetryAfter(apiErr.Response.Header)
+		}
+		return pe
+	}
+	return err
+}
+
+func (p *openaiProvider) Complete(ctx context.Context, model string, msgs []*pb.ChatMessage) (*completion, error) {
+	resp, err := p.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Messages: toOpenAIMessages(msgs),
+		Model:    openai.ChatModel(model),
+	})
+	if err != nil {
+		return nil, fromOpenAIError(err)
+	}
+
+	reply := ""
+	if len(resp.Choices) > 0 {
+		reply = resp.Choices[0].Message.Content
+	}
+
+	return &completion{
+		Reply:            reply,
+		PromptTokens:     int32(resp.Usage.PromptTokens),
+		CompletionTokens: int32(resp.Usage.CompletionTokens),
+		TotalTokens:      int32(resp.Usage.TotalTokens),
+	}, nil
+}
+
+// Stream 基于 OpenAI 流式接口；开启 include_usage 后最后一个 chunk 带真实用量
+func (p *openaiProvider) Stream(ctx context.Context, model string, msgs []*pb.ChatMessage, onDelta func(string) error) (*completion, error) {
+	stream := p.client.Chat.Completions.NewStreaming(ctx, openai.ChatCompletionNewParams{
+		Messages: toOpenAIMessages(msgs),
+		Model:    openai.ChatModel(model),
+		StreamOptions: openai.ChatCompletionStreamOptionsParam{
+			IncludeUsage: openai.Bool(true),
+		},
+	})
+	defer stream.Close()
+
+	var acc openai.ChatCompletionAccumulator
+	for stream.Next() {
+		chunk := stream.Current()
+		acc.AddChunk(chunk)
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+		if err := onDelta(chunk.Choices[0].Delta.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, fromOpenAIError(err)
+	}
+
+	reply := ""
+	if len(acc.Choices) > 0 {
+		reply = acc.Choices[0].Message.Content
+	}
+	return &completion{
+		Reply:            reply,
+		PromptTokens:     int32(acc.Usage.PromptTokens),
+		CompletionTokens: int32(acc.Usage.CompletionTokens),
+		TotalTokens:      int32(acc.Usage.TotalTokens),
+	}, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/config"
+)
+
+// completion 是一次生成的结果：完整回复 + 真实（或模拟的）token 用量
+type completion struct {
+	Reply            string
+	PromptTokens     int32
+	CompletionTokens int32
+	TotalTokens      int32
+}
+
+// provider 抽象一个 LLM 后端。model 由路由层决定；
+// msgs 已按时间顺序排好并按预算裁剪，最后一条为本轮提问。
+type provider interface {
+	Complete(ctx context.Context, model string, msgs []*pb.ChatMessage) (*completion, error)
+	// Stream 每收到一段增量就回调 onDelta；结束后返回完整回复与用量
+	Stream(ctx context.Context, model string, msgs []*pb.ChatMessage, onDelta func(string) error) (*completion, error)
+}
+
+// providerError 是后端返回的可识别错误（额度不足、限速等），Status 为对应的 HTTP 状态码
+type providerError struct {
+	Status     int
+	Code       string
+	Msg        string
+	RetryAfter time.Duration // 上游建议的重试间隔（没有则为 0）
+}
+
+func (e *providerError) Error() string {
+	return fmt.Sprintf("%d %s: %s (%s)", e.Status, http.StatusText(e.Status), e.Msg, e.Code)
+}
+
+// newProvider 按 llm.provider（LLM_PROVIDER）选择后端：openai（默认）或 mock（离线、确定性）
+func newProvider(cfg config.LLM) (provider, error) {
+	switch cfg.Provider {
+	case "openai":
+		return newOpenAIProvider(cfg.OpenAI.APIKey)
+	case "mock":
+		return newMockProvider(cfg.Mock), nil
+	default:
+		return nil, fmt.Errorf("unknown llm provider %q (want openai|mock)", cfg.Provider)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/logging"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// route 是回退链上的一个模型；timeout 为该模型单次尝试的时限（0 表示只受调用方 deadline 约束）
+type route struct {
+	model   string
+	timeout time.Duration
+}
+
+func (r route) String() string {
+	if r.timeout <= 0 {
+		return r.model
+	}
+	return r.model + ":" + r.timeout.String()
+}
+
+// 单个模型超时：与调用方自己的取消/超时区分开，前者才值得换下一个模型
+var errModelTimeout = errors.New("model attempt timed out")
+
+// parseRoutes 解析 LLM_MODELS，如 "gpt-4o-mini:10s,gpt-4.1-nano:8s"。
+// 列表既是白名单，也是默认回退顺序（第一个为默认模型）。
+func parseRoutes(spec, fallback string) ([]route, error) {
+	if strings.TrimSpace(spec) == "" {
+		return []route{{model: fallback}}, nil
+	}
+	var routes []route
+	for _, part := range strings.Split(spec, ",") {
+		name, dur, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if name == "" {
+			continue
+		}
+		rt := route{model: name}
+		if dur != "" {
+			d, err := time.ParseDuration(dur)
+			if err != nil {
+				return nil, fmt.Errorf("LLM_MODELS: bad timeout for %s: %w", name, err)
+			}
+			rt.timeout = d
+		}
+		routes = append(routes, rt)
+	}
+	if len(routes) == 0 {
+		return nil, errors.New("LLM_MODELS is empty")
+	}
+	return routes, nil
+}
+
+// chain 返回本次请求依次尝试的模型：指定的模型排第一，其余按配置顺序回退
+func (s *server) chain(requested string) ([]route, error) {
+	if requested == "" {
+		return s.routes, nil
+	}
+	out := make([]route, 0, len(s.routes))
+	for _, rt := range s.routes {
+		if rt.model == requested {
+			out = append(out, rt)
+		}
+	}
+	if len(out) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "model %q is not allowed", requested)
+	}
+	for _, rt := range s.routes {
+		if rt.model != requested {
+			out = append(out, rt)
+		}
+	}
+	return out, nil
+}
+
+// attempt 为单个模型创建带时限的上下文
+func attempt(ctx context.Context, rt route) (context.Context, context.CancelFunc) {
+	if rt.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, rt.timeout, errModelTimeout)
+}
+
+// retryable 判断失败后是否换下一个模型：限速（额度不足除外）、5xx、单模型超时。
+// 调用方已取消或整体超时则不再重试。
+func retryable(parent, actx context.Context, err error) bool {
+	if parent.Err() != nil {
+		return false
+	}
+	if errors.Is(context.Cause(actx), errModelTimeout) {
+		return true
+	}
+	var pe *providerError
+	if errors.As(err, &pe) {
+		if pe.Code == "insufficient_quota" { // 账户级问题，换模型也没用
+			return false
+		}
+		return pe.Status == http.StatusTooManyRequests || pe.Status >= 500
+	}
+	return false
+}
+
+// timeoutCause 把单模型超时（流式时表现为 Canceled）统一成 DeadlineExceeded，并注明模型
+func timeoutCause(actx context.Context, rt route, err error) error {
+	if err != nil && errors.Is(context.Cause(actx), errModelTimeout) {
+		return status.Errorf(codes.DeadlineExceeded, "model %s timed out after %s", rt.model, rt.timeout)
+	}
+	return err
+}
+
+// complete 按回退链依次尝试，返回成功的结果与实际作答的模型
+func (s *server) complete(ctx context.Context, in *pb.ChatRequest) (*completion, string, error) {
+	routes, err := s.chain(in.GetModel())
+	if err != nil {
+		return nil, "", err
+	}
+	msgs := s.buildMessages(in)
+
+	var lastErr error
+	for _, rt := range routes {
+		actx, cancel := attempt(ctx, rt)
+		actx, span := startAttempt(actx, rt)
+		c, err := s.provider.Complete(actx, rt.model, msgs)
+		retry := err != nil && retryable(ctx, actx, err)
+		err = timeoutCause(actx, rt, err)
+		endAttempt(span, c, err)
+		cancel()
+		if err == nil {
+			return c, rt.model, nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+		llmFallbacks.WithLabelValues(rt.model).Inc()
+		logging.FromContext(ctx).Warn("model failed, falling back", "model", rt.model, "error", err)
+	}
+	return nil, "", lastErr
+}
+
+// stream 与 complete 相同，但一旦已经向调用方推送过增量就不能再回退。
+// 流式时单模型时限只约束首个增量（首字延迟），之后的生成只受调用方 deadline 约束。
+func (s *server) stream(ctx context.Context, in *pb.ChatRequest, onDelta func(string) error) (*completion, string, error) {
+	routes, err := s.chain(in.GetModel())
+	if err != nil {
+		return nil, "", err
+	}
+	msgs := s.buildMessages(in)
+
+	var lastErr error
+	for _, rt := range routes {
+		sent := false
+		actx, cancel := context.WithCancelCause(ctx)
+		stop := func() bool { return false }
+		if rt.timeout > 0 {
+			stop = time.AfterFunc(rt.timeout, func() { cancel(errModelTimeout) }).Stop
+		}
+		actx, span := startAttempt(actx, rt)
+		c, err := s.provider.Stream(actx, rt.model, msgs, func(d string) error {
+			if !sent {
+				stop()
+				sent = true
+				span.AddEvent("first_token")
+			}
+			return onDelta(d)
+		})
+		stop()
+		retry := err != nil && !sent && retryable(ctx, actx, err)
+		err = timeoutCause(actx, rt, err)
+		endAttempt(span, c, err)
+		cancel(nil)
+		if err == nil {
+			return c, rt.model, nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+		llmFallbacks.WithLabelValues(rt.model).Inc()
+		logging.FromContext(ctx).Warn("model failed before first token, falling back", "model", rt.model, "error", err)
+	}
+	return nil, "", lastErr
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	pb "chatgpt-demo/chatpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestServer(t *testing.T, p provider, models string) *server {
+	t.Helper()
+	routes, err := parseRoutes(models, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &server{provider: p, routes: routes, budget: 1000}
+}
+
+func TestFallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantModel string
+		wantCode  codes.Code // 不回退时最终的错误
+	}{
+		{"ok", "hi", "m1", codes.OK},
+		{"rate limited falls back", "hi [mock:rate_limit@m1]", "m2", codes.OK},
+		{"5xx falls back", "hi [mock:unavailable@m1]", "m2", codes.OK},
+		{"every model fails", "hi [mock:unavailable]", "", codes.Unavailable},
+		{"quota does not fall back", "hi [mock:insufficient_quota@m1]", "", codes.ResourceExhausted},
+	}
+	s := newTestServer(t, &mockProvider{}, "m1,m2")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := &pb.ChatRequest{UserId: "u1", Text: tt.text}
+
+			c, model, err := s.complete(context.Background(), in)
+			checkRoute(t, "complete", c, model, err, tt.wantModel, tt.wantCode)
+
+			var deltas string
+			c, model, err = s.stream(context.Background(), in, func(d string) error {
+				deltas += d
+				return nil
+			})
+			checkRoute(t, "stream", c, model, err, tt.wantModel, tt.wantCode)
+			if err == nil && deltas != c.Reply {
+				t.Fatalf("stream deltas = %q, reply = %q", deltas, c.Reply)
+			}
+		})
+	}
+}
+
+func checkRoute(t *testing.T, what string, c *completion, model string, err error, wantModel string, wantCode codes.Code) {
+	t.Helper()
+	if wantCode != codes.OK {
+		if got := status.Code(toStatus(err)); got != wantCode {
+			t.Fatalf("%s: err = %v (%s), want %s", what, err, got, wantCode)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("%s: err = %v", what, err)
+	}
+	if model != wantModel || c == nil {
+		t.Fatalf("%s: answered by %q, want %q", what, model, wantModel)
+	}
+}
+
+// midStreamProvider 推送一段后出错，用来确认已推送过增量就不再回退
+type midStreamProvider struct{ calls []string }
+
+func (p *midStreamProvider) Complete(ctx context.Context, model string, msgs []*pb.ChatMessage) (*completion, error) {
+	return nil, errors.New("not used")
+}
+
+func (p *midStreamProvider) Stream(ctx context.Context, model string, msgs []*pb.ChatMessage, onDelta func(string) error) (*completion, error) {
+	p.calls = append(p.calls, model)
+	if err := onDelta("partial "); err != nil {
+		return nil, err
+	}
+	return nil, &providerError{Status: http.StatusServiceUnavailable, Code: "server_error", Msg: "overloaded"}
+}
+
+func TestStreamNoFallbackAfterFirstDelta(t *testing.T) {
+	p := &midStreamProvider{}
+	s := newTestServer(t, p, "m1,m2")
+	_, _, err := s.stream(context.Background(), &pb.ChatRequest{Text: "hi"}, func(string) error { return nil })
+	if status.Code(toStatus(err)) != codes.Unavailable {
+		t.Fatalf("err = %v, want Unavailable", err)
+	}
+	if len(p.calls) != 1 {
+		t.Fatalf("models tried = %v, want only m1", p.calls)
+	}
+}
+
+func TestChainRejectsUnknownModel(t *testing.T) {
+	s := newTestServer(t, &mockProvider{}, "m1,m2")
+	_, _, err := s.complete(context.Background(), &pb.ChatRequest{Text: "hi", Model: "m3"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("err = %v, want InvalidArgument", err)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("chatgpt-demo/llmserver")
+
+// startAttempt 为回退链上的一次模型尝试开一个 span；OpenAI 的 HTTP 请求（otelhttp）挂在它下面
+func startAttempt(ctx context.Context, rt route) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "chat "+rt.model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.GenAIOperationNameChat, semconv.GenAIRequestModel(rt.model)),
+	)
+}
+
+// endAttempt 记录用量或错误并结束 span
+func endAttempt(span trace.Span, c *completion, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if c != nil {
+		span.SetAttributes(
+			semconv.GenAIUsageInputTokens(int(c.PromptTokens)),
+			semconv.GenAIUsageOutputTokens(int(c.CompletionTokens)),
+		)
+	}
+	span.End()
+}
@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// 请求消息都带 user_id（pb 生成的 GetUserId），有则记进日志
+type userIDGetter interface{ GetUserId() string }
+
+func userOf(req any) string {
+	if g, ok := req.(userIDGetter); ok {
+		return g.GetUserId()
+	}
+	return ""
+}
+
+// incoming 从 gRPC metadata 取 request_id（调用方没带时新生成一个）放进 ctx
+func incoming(ctx context.Context) context.Context {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(mdRequestID); len(v) > 0 && ValidRequestID(v[0]) {
+			id = v[0]
+		}
+	}
+	if id == "" {
+		id = NewRequestID()
+	}
+	return WithRequestID(ctx, id)
+}
+
+// outgoing 把 ctx 里的 request_id 写进发往下游的 metadata
+func outgoing(ctx context.Context) context.Context {
+	if id := RequestID(ctx); id != "" {
+		return metadata.AppendToOutgoingContext(ctx, mdRequestID, id)
+	}
+	return ctx
+}
+
+// logRPC 记录一次 RPC：OK 为 info，服务端故障（Internal/Unknown/Unavailable/DataLoss）为 error，其余为 warn
+func logRPC(ctx context.Context, method, user string, err error, start time.Time) {
+	st := status.Convert(err)
+	level := slog.LevelInfo
+	switch st.Code() {
+	case codes.OK:
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", st.Code().String()),
+		slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+	}
+	if user != "" {
+		attrs = append(attrs, slog.String("user_id", user))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", st.Message()))
+	}
+	FromContext(ctx).LogAttrs(ctx, level, "rpc", attrs...)
+}
+
+// UnaryServerInterceptor 接收上游的 request_id 并记录每次一元 RPC
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx = incoming(ctx)
+	resp, err := handler(ctx, req)
+	logRPC(ctx, info.FullMethod, userOf(req), err, start)
+	return resp, err
+}
+
+// StreamServerInterceptor 与一元版相同；user_id 取自流上收到的第一条请求
+func StreamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ws := &serverStream{ServerStream: ss, ctx: incoming(ss.Context())}
+	err := handler(srv, ws)
+	logRPC(ws.ctx, info.FullMethod, ws.user, err, start)
+	return err
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	user string
+}
+
+func (s *serverStream) Context() context.Context { return s.ctx }
+
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && s.user == "" {
+		s.user = userOf(m)
+	}
+	return err
+}
+
+// UnaryClientInterceptor 把 request_id 透传给下游
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(outgoing(ctx), method, req, reply, cc, opts...)
+}
+
+// StreamClientInterceptor 把 request_id 透传给下游
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(outgoing(ctx), desc, cc, method, opts...)
+}
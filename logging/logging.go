@@ -0,0 +1,73 @@
+// Package logging 汇总各服务共用的结构化日志：基于 log/slog 的 JSON 输出、request_id 的生成与透传，
+// 以及记录每次 RPC 的 gRPC 拦截器。网关的 HTTP 访问日志中间件在 gateway 里。
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderRequestID 是网关接收/返回 request_id 的 HTTP 头；gRPC 里以小写 metadata key 透传
+const (
+	HeaderRequestID = "X-Request-ID"
+	mdRequestID     = "x-request-id"
+)
+
+type ctxKey struct{}
+
+// Init 把默认 logger 设为 JSON 输出到标准输出，每行带 service；级别取 LOG_LEVEL（debug|info|warn|error，默认 info）。
+// 标准库 log 的输出（各 main 里的 log.Fatal）也会经由它写成 JSON，级别记为 error。
+func Init(service string) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv("LOG_LEVEL"))); err != nil {
+		level = slog.LevelInfo
+	}
+	l := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})).With("service", service)
+	slog.SetDefault(l)
+	slog.SetLogLoggerLevel(slog.LevelError)
+	return l
+}
+
+// NewRequestID 生成 32 位十六进制的 request_id
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ValidRequestID 校验外部传入的 X-Request-ID：非空、不超过 128 个字符、只含可打印 ASCII
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	return strings.IndexFunc(id, func(r rune) bool { return r < 0x21 || r > 0x7e }) < 0
+}
+
+// WithRequestID 把 request_id 放进 ctx
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// RequestID 取出 ctx 里的 request_id（没有时为空）
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// FromContext 返回带 request_id / trace_id 的 logger，同一请求在各服务的日志可以互相关联
+func FromContext(ctx context.Context) *slog.Logger {
+	l := slog.Default()
+	if id := RequestID(ctx); id != "" {
+		l = l.With("request_id", id)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		l = l.With("trace_id", sc.TraceID().String())
+	}
+	return l
+}
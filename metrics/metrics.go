@@ -0,0 +1,160 @@
+// Package metrics 汇总各服务共用的 Prometheus 指标：gRPC 服务端/客户端拦截器、连接池指标与 /metrics 暴露。
+// 业务指标（过滤拦截、配额拒绝、LLM 用量等）由各服务自己定义，同样注册到默认 Registry。
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LatencyBuckets 覆盖本地 RPC（毫秒级）到 LLM 生成（数十秒）
+var LatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+var (
+	serverHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "RPCs completed on the server, by method and status code.",
+	}, []string{"method", "code"})
+	serverSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Server-side RPC latency (streams: until the stream ends), by method and status code.",
+		Buckets: LatencyBuckets,
+	}, []string{"method", "code"})
+	clientSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_client_handling_seconds",
+		Help:    "Client-side RPC latency as seen by the caller (streams: until fully received), by method and status code.",
+		Buckets: LatencyBuckets,
+	}, []string{"method", "code"})
+)
+
+// UnaryServerInterceptor 记录每个一元 RPC 的次数与耗时
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	observeServer(info.FullMethod, err, start)
+	return resp, err
+}
+
+// StreamServerInterceptor 记录每个流式 RPC 的次数与总耗时
+func StreamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	observeServer(info.FullMethod, err, start)
+	return err
+}
+
+func observeServer(method string, err error, start time.Time) {
+	code := status.Code(err).String()
+	serverHandled.WithLabelValues(method, code).Inc()
+	serverSeconds.WithLabelValues(method, code).Observe(time.Since(start).Seconds())
+}
+
+// UnaryClientInterceptor 在调用方（网关）记录各后端 RPC 的耗时，即 filter / token / llm / history 各阶段耗时
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	clientSeconds.WithLabelValues(method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// StreamClientInterceptor 记录流式 RPC（如 GenerateStream）从发起到收完（EOF 或出错）的总耗时
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	start := time.Now()
+	cs, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		clientSeconds.WithLabelValues(method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+		return nil, err
+	}
+	return &observedStream{ClientStream: cs, method: method, start: start}, nil
+}
+
+type observedStream struct {
+	grpc.ClientStream
+	method string
+	start  time.Time
+	once   sync.Once
+}
+
+func (s *observedStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.once.Do(func() {
+			code := status.Code(err)
+			if err == io.EOF {
+				code = codes.OK
+			}
+			clientSeconds.WithLabelValues(s.method, code.String()).Observe(time.Since(s.start).Seconds())
+		})
+	}
+	return err
+}
+
+// Handler 返回 /metrics 的 HTTP handler
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Serve 在独立端口上暴露 /metrics（gRPC 服务用），后台运行；监听失败只记日志，不影响 RPC
+func Serve(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	go func() {
+		slog.Info("metrics listening", "addr", addr, "path", "/metrics")
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			slog.Error("metrics server stopped", "error", err)
+		}
+	}()
+}
+
+// DBPool 注册 MySQL 连接池指标（go_sql_*，db_name 为标签）
+func DBPool(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RedisPool 注册 Redis 连接池指标（redis_pool_*）
+func RedisPool(rdb *redis.Client) {
+	prometheus.MustRegister(&redisPoolCollector{rdb: rdb})
+}
+
+var (
+	redisHits     = prometheus.NewDesc("redis_pool_hits_total", "Times a free connection was found in the pool.", nil, nil)
+	redisMisses   = prometheus.NewDesc("redis_pool_misses_total", "Times a free connection was NOT found in the pool.", nil, nil)
+	redisTimeouts = prometheus.NewDesc("redis_pool_timeouts_total", "Times a wait timeout occurred.", nil, nil)
+	redisTotal    = prometheus.NewDesc("redis_pool_conns", "Connections in the pool.", nil, nil)
+	redisIdle     = prometheus.NewDesc("redis_pool_idle_conns", "Idle connections in the pool.", nil, nil)
+)
+
+type redisPoolCollector struct {
+	rdb *redis.Client
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisHits
+	ch <- redisMisses
+	ch <- redisTimeouts
+	ch <- redisTotal
+	ch <- redisIdle
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.rdb.PoolStats()
+	ch <- prometheus.MustNewConstMetric(redisHits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(redisMisses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(redisTimeouts, prometheus.CounterValue, float64(s.Timeouts))
+	ch <- prometheus.MustNewConstMetric(redisTotal, prometheus.GaugeValue, float64(s.TotalConns))
+	ch <- prometheus.MustNewConstMetric(redisIdle, prometheus.GaugeValue, float64(s.IdleConns))
+}
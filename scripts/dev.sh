@@ -7,11 +7,11 @@ LOG_DIR="$ROOT_DIR/logs"
 PID_DIR="$ROOT_DIR/.pids"
 mkdir -p "$LOG_DIR" "$PID_DIR"
 
-# ===== 可配置环境变量（这里给出默认值，可在外部 export 覆盖）=====
-export REDIS_ADDR="${REDIS_ADDR:-localhost:6379}"
-export MYSQL_DSN="${MYSQL_DSN:-root:root@tcp(localhost:3306)/chatdb?parseTime=true&charset=utf8mb4,utf8}"
-export OPENAI_MODEL="${OPENAI_MODEL:-gpt-4o-mini}"
-DAILY_LIMIT="${DAILY_LIMIT:-5000}"    # tokenserver 每日限额（仅用于日志展示）
+# ===== 配置 =====
+# 默认值都在 configs/config.yaml；APP_ENV=dev|prod 叠加 configs/config.<APP_ENV>.yaml。
+# 环境变量（REDIS_ADDR、MYSQL_DSN、LLM_PROVIDER、DAILY_LIMIT 等）优先于配置文件，可在外部 export 覆盖。
+export CONFIG_FILE="${CONFIG_FILE:-$ROOT_DIR/configs/config.yaml}"
+export APP_ENV="${APP_ENV:-}"
 # OPENAI_API_KEY 必须由你在 shell 里 export；脚本不保存你的密钥
 
 info(){ echo -e "\033[1;34m[INFO]\033[0m $*"; }
@@ -28,8 +28,8 @@ regen_proto() {
 }
 
 need_key() {
-  if [[ -z "${OPENAI_API_KEY:-}" ]]; then
-    warn "OPENAI_API_KEY not set. llmserver will fail to call OpenAI. Export it before running."
+  if [[ -z "${OPENAI_API_KEY:-}" ]] && ! (cd "$ROOT_DIR" && go run ./llmserver -print-config >/dev/null 2>&1); then
+    warn "llmserver config is invalid (usually OPENAI_API_KEY not set). Export it, or use LLM_PROVIDER=mock / APP_ENV=dev to run offline."
   fi
 }
 
@@ -66,25 +66,29 @@ start_all() {
   # 依次启动（本地依赖假定已就绪：Redis, MySQL）
   start_one tokenserver  "go run ./tokenserver"
   start_one historyserver "go run ./historyserver"
+  start_one authserver   "go run ./authserver"
   start_one filterserver "go run ./filterserver"
   start_one llmserver    "go run ./llmserver"
   start_one gateway      "go run ./gateway"
   info "All services started."
-  info "Redis: $REDIS_ADDR | MySQL: $MYSQL_DSN | DailyLimit: $DAILY_LIMIT | Model: ${OPENAI_MODEL}"
+  info "Config: $CONFIG_FILE | Profile: ${APP_ENV:-(none)}"
+  info "Effective config: go run ./<service> -print-config"
   info "Tail logs:   tail -f $LOG_DIR/*.log"
+  info "First API key: go run ./authserver -issue-admin <user_id>"
 }
 
 stop_all() {
   stop_one gateway
   stop_one llmserver
   stop_one filterserver
+  stop_one authserver
   stop_one historyserver
   stop_one tokenserver
   info "All services stopped."
 }
 
 status() {
-  for n in tokenserver historyserver filterserver llmserver gateway; do
+  for n in tokenserver historyserver authserver filterserver llmserver gateway; do
     if is_running "$n"; then
       echo "✔ $n (pid $(cat "$PID_DIR/$n.pid")) log: $LOG_DIR/$n.log"
     else
@@ -146,19 +150,26 @@ Commands:
   down            Stop all services
   restart         Stop then start
   status          Show running status
-  logs [name]     Tail logs (all or one of: tokenserver|historyserver|filterserver|llmserver|gateway)
+  logs [name]     Tail logs (all or one of: tokenserver|historyserver|authserver|filterserver|llmserver|gateway)
   deps up         Start Redis & MySQL via Docker
   deps down       Stop Redis & MySQL containers
 
 Env (override as needed):
-  OPENAI_API_KEY   (required for llmserver)
-  OPENAI_MODEL     default: $OPENAI_MODEL
-  REDIS_ADDR       default: $REDIS_ADDR
-  MYSQL_DSN        default: $MYSQL_DSN
+  CONFIG_FILE      default: $CONFIG_FILE (.yaml/.yml/.toml)
+  APP_ENV          profile overlay, e.g. dev|prod (configs/config.<APP_ENV>.yaml)
+  OPENAI_API_KEY   (required when llm.provider=openai)
+  LLM_PROVIDER, REDIS_ADDR, MYSQL_DSN, DAILY_LIMIT, RATE_LIMITS, ...
+                   override the matching config keys (see configs/config.yaml)
+  OTEL_TRACES_EXPORTER  none (default) | otlp | stdout | file
+  LOG_LEVEL        default: info (debug|info|warn|error; logs are JSON lines)
 
 Examples:
   OPENAI_API_KEY=sk-xxx scripts/dev.sh up
+  LLM_PROVIDER=mock MOCK_LATENCY=300ms scripts/dev.sh up
+  APP_ENV=dev scripts/dev.sh up             # 离线 mock + 放宽限流
+  go run ./gateway -print-config            # 查看生效配置（密钥打码）
   scripts/dev.sh deps up && scripts/dev.sh up
+  go run ./authserver -issue-admin ops      # 签发首个管理员 API Key
   scripts/dev.sh logs gateway
   scripts/dev.sh down
 EOF
@@ -0,0 +1,13 @@
+-- 已有数据库升级：增加会话表，并给 chat_history 增加 conversation_id
+USE chatdb;
+CREATE TABLE IF NOT EXISTS conversations (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  user_id VARCHAR(64) NOT NULL,
+  title VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY idx_user_updated (user_id, updated_at)
+) ENGINE=InnoDB;
+ALTER TABLE chat_history
+  ADD COLUMN conversation_id BIGINT NULL AFTER user_id,
+  ADD KEY idx_conversation (conversation_id, id);
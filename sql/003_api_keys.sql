@@ -0,0 +1,16 @@
+-- 已有数据库升级：API Key（只存哈希）
+USE chatdb;
+CREATE TABLE IF NOT EXISTS api_keys (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  key_hash CHAR(64) NOT NULL,
+  prefix VARCHAR(16) NOT NULL,
+  user_id VARCHAR(64) NOT NULL DEFAULT '',
+  tenant_id VARCHAR(64) NOT NULL DEFAULT '',
+  scopes VARCHAR(255) NOT NULL,
+  name VARCHAR(64) NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  revoked_at TIMESTAMP NULL,
+  UNIQUE KEY uk_hash (key_hash),
+  KEY idx_user (user_id),
+  KEY idx_tenant (tenant_id)
+) ENGINE=InnoDB;
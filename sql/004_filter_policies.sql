@@ -0,0 +1,34 @@
+-- 已有数据库升级：过滤规则版本与审计日志（filter.store=mysql）
+USE chatdb;
+-- 每个版本是一份完整的规则文件（YAML），对外版本号为 v<id>
+CREATE TABLE IF NOT EXISTS filter_policies (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  parent_id BIGINT NULL,
+  body MEDIUMTEXT NOT NULL,
+  rule_count INT NOT NULL DEFAULT 0,
+  created_by VARCHAR(64) NOT NULL DEFAULT '',
+  comment VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB;
+-- 只有一行：最新版本（编辑的基础）与当前生效的版本；改动时锁住这一行，保证版本串行生成
+CREATE TABLE IF NOT EXISTS filter_policy_state (
+  id TINYINT PRIMARY KEY,
+  latest_id BIGINT NULL,
+  active_id BIGINT NULL,
+  activated_by VARCHAR(64) NOT NULL DEFAULT '',
+  activated_at TIMESTAMP NULL
+) ENGINE=InnoDB;
+INSERT IGNORE INTO filter_policy_state(id) VALUES (1);
+CREATE TABLE IF NOT EXISTS filter_audit (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  actor VARCHAR(64) NOT NULL,
+  actor_key VARCHAR(32) NOT NULL DEFAULT '',
+  action VARCHAR(32) NOT NULL,
+  target VARCHAR(64) NOT NULL,
+  policy_id BIGINT NULL,
+  before_json TEXT NULL,
+  after_json TEXT NULL,
+  comment VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY idx_target (target, id)
+) ENGINE=InnoDB;
@@ -0,0 +1,4 @@
+-- 已有数据库升级：用户维度的历史翻页（WHERE user_id=? AND id<? ORDER BY id）走 (user_id, id) 索引
+USE chatdb;
+ALTER TABLE chat_history
+  ADD KEY idx_user_id (user_id, id);
@@ -1,9 +1,66 @@
 CREATE DATABASE IF NOT EXISTS chatdb;
 USE chatdb;
+CREATE TABLE IF NOT EXISTS conversations (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  user_id VARCHAR(64) NOT NULL,
+  title VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY idx_user_updated (user_id, updated_at)
+) ENGINE=InnoDB;
 CREATE TABLE IF NOT EXISTS chat_history (
   id BIGINT PRIMARY KEY AUTO_INCREMENT,
   user_id VARCHAR(64) NOT NULL,
+  conversation_id BIGINT NULL,
   role ENUM('user','assistant') NOT NULL,
   text TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY idx_user_id (user_id, id),
+  KEY idx_conversation (conversation_id, id)
+) ENGINE=InnoDB;
+CREATE TABLE IF NOT EXISTS api_keys (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  key_hash CHAR(64) NOT NULL,
+  prefix VARCHAR(16) NOT NULL,
+  user_id VARCHAR(64) NOT NULL DEFAULT '',
+  tenant_id VARCHAR(64) NOT NULL DEFAULT '',
+  scopes VARCHAR(255) NOT NULL,
+  name VARCHAR(64) NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  revoked_at TIMESTAMP NULL,
+  UNIQUE KEY uk_hash (key_hash),
+  KEY idx_user (user_id),
+  KEY idx_tenant (tenant_id)
+) ENGINE=InnoDB;
+-- 过滤规则版本：每个版本是一份完整的规则文件（YAML），对外版本号为 v<id>
+CREATE TABLE IF NOT EXISTS filter_policies (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  parent_id BIGINT NULL,
+  body MEDIUMTEXT NOT NULL,
+  rule_count INT NOT NULL DEFAULT 0,
+  created_by VARCHAR(64) NOT NULL DEFAULT '',
+  comment VARCHAR(255) NOT NULL DEFAULT '',
   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 ) ENGINE=InnoDB;
+-- 只有一行：最新版本（编辑的基础）与当前生效的版本；改动时锁住这一行，保证版本串行生成
+CREATE TABLE IF NOT EXISTS filter_policy_state (
+  id TINYINT PRIMARY KEY,
+  latest_id BIGINT NULL,
+  active_id BIGINT NULL,
+  activated_by VARCHAR(64) NOT NULL DEFAULT '',
+  activated_at TIMESTAMP NULL
+) ENGINE=InnoDB;
+INSERT IGNORE INTO filter_policy_state(id) VALUES (1);
+CREATE TABLE IF NOT EXISTS filter_audit (
+  id BIGINT PRIMARY KEY AUTO_INCREMENT,
+  actor VARCHAR(64) NOT NULL,
+  actor_key VARCHAR(32) NOT NULL DEFAULT '',
+  action VARCHAR(32) NOT NULL,
+  target VARCHAR(64) NOT NULL,
+  policy_id BIGINT NULL,
+  before_json TEXT NULL,
+  after_json TEXT NULL,
+  comment VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY idx_target (target, id)
+) ENGINE=InnoDB;
@@ -2,88 +2,108 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
+	"log/slog"
 	"net"
-	"os"
 	"time"
 
 	pb "chatgpt-demo/chatpb"
+	"chatgpt-demo/config"
+	"chatgpt-demo/logging"
+	"chatgpt-demo/metrics"
+	"chatgpt-demo/tracing"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 )
 
 type server struct {
 	pb.UnimplementedTokenServiceServer
-	rdb   *redis.Client
-	limit int64
+	rdb *redis.Client
+	// 配额窗口，一次请求要同时满足全部窗口
+	quotas []quota
+	// 预占默认有效期：超过后未 commit/release 的预占由 reaper 自动退回
+	holdTTL time.Duration
 }
 
-func dayKey(user string) string {
-	return fmt.Sprintf("token:%s:%s", user, time.Now().Format("2006-01-02"))
-}
-
-// seconds until next 02:00 (给足一整天 + 缓冲，简单起见用 48h)
-func ttl() time.Duration { return 48 * time.Hour }
+// 各窗口的校验 + 计数 + 下限保护 + 设置 TTL，与调用它的脚本一起在 Redis 里原子完成：
+// 任一窗口正向超限就整体拒绝且不写入，并发请求不会看到中间态，也不会互相回滚。
+// 由外层脚本先定义 n（窗口数）；KEYS[1..n]=各窗口计数 key  ARGV[3i-2..3i]=第 i 个窗口的 (增量, 上限, TTL 秒)
+// 执行后 res = {allowed(0/1), 拒绝的窗口序号（放行时为 0）, 各窗口计数...}，被拒绝时外层脚本应直接返回 res
+const applyQuotasLua = `
+local res, cur = {1, 0}, {}
+for i = 1, n do
+  cur[i] = tonumber(redis.call('GET', KEYS[i]) or '0')
+  local delta = tonumber(ARGV[3*i-2])
+  if res[1] == 1 and delta > 0 and cur[i] + delta > tonumber(ARGV[3*i-1]) then res = {0, i} end
+end
+if res[1] == 1 then
+  for i = 1, n do
+    local val = cur[i] + tonumber(ARGV[3*i-2])
+    if val < 0 then val = 0 end
+    if val ~= cur[i] then redis.call('INCRBY', KEYS[i], val - cur[i]) end
+    if redis.call('TTL', KEYS[i]) < 0 then redis.call('EXPIRE', KEYS[i], ARGV[3*i]) end
+    cur[i] = val
+  end
+end
+for i = 1, n do res[i+2] = cur[i] end
+`
+
+var checkAndIncScript = redis.NewScript(`local n = #KEYS` + applyQuotasLua + `return res`)
 
 func (s *server) CheckAndInc(ctx context.Context, in *pb.TokenRequest) (*pb.TokenReply, error) {
-	key := dayKey(in.UserId)
-	delta := int64(in.Tokens)
-
-	// 先增（或回冲），再校验；正向超限则回滚
-	val, err := s.rdb.IncrBy(ctx, key, delta).Result()
+	now := time.Now()
+	keys, args := s.windows(in.UserId, in.Tokens, now)
+	res, err := checkAndIncScript.Run(ctx, s.rdb, keys, args...).Int64Slice()
 	if err != nil {
 		return nil, err
 	}
 
-	// 设置 TTL（仅首次/无 TTL 时）
-	_ = s.rdb.ExpireNX(ctx, key, ttl()).Err()
-
-	// 下限保护：如变成负数，纠正回 0
-	if val < 0 {
-		diff := -val
-		if err := s.rdb.IncrBy(ctx, key, diff).Err(); err != nil {
-			return nil, err
-		}
-		val = 0
-	}
-
-	// 超限判断（仅正向消耗时）
-	allowed := true
-	if delta > 0 && val > s.limit {
-		allowed = false
-		// 回滚刚才的增量
-		_ = s.rdb.IncrBy(ctx, key, -delta).Err()
-		val, _ = s.rdb.Get(ctx, key).Int64()
+	reply := s.verdict(res, now)
+	if !reply.Allowed {
+		quotaDenied.WithLabelValues("CheckAndInc", reply.Window).Inc()
 	}
-
-	remaining := s.limit - val
-	return &pb.TokenReply{Allowed: allowed, Remaining: remaining}, nil
+	return reply, nil
 }
 
 func main() {
-	addr := os.Getenv("REDIS_ADDR")
-	if addr == "" {
-		addr = "localhost:6379"
-	}
-	limit := int64(5000) // 可用环境变量配置
-	if v := os.Getenv("DAILY_LIMIT"); v != "" {
-		if n, err := fmt.Sscanf(v, "%d", &limit); n == 0 || err != nil {
-		}
+	logging.Init("tokenserver")
+	cfg := config.MustLoad("tokenserver")
+
+	shutdown, err := tracing.Init(context.Background(), "tokenserver")
+	if err != nil {
+		log.Fatal(err)
 	}
+	defer shutdown(context.Background())
+
+	rdb := redis.NewClient(&redis.Options{Addr: cfg.Redis.Addr})
+	tracing.InstrumentRedis(rdb)
+	metrics.RedisPool(rdb)
 
-	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	metrics.Serve(cfg.Token.MetricsAddr)
 
-	lis, err := net.Listen("tcp", ":50051")
+	lis, err := net.Listen("tcp", cfg.Token.Addr)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	s := grpc.NewServer()
-	pb.RegisterTokenServiceServer(s, &server{rdb: rdb, limit: limit})
-
-	log.Println("Token (Redis) service @ :50051, limit =", limit, "redis =", addr)
+	quotas, err := parseQuotas(cfg.Token.Quotas, cfg.Token.DailyLimit)
+	if err != nil {
+		log.Fatalf("token.quotas: %v", err)
+	}
+	srv := &server{rdb: rdb, quotas: quotas, holdTTL: cfg.Token.HoldTTL.Duration}
+	// 后台回收过期预占
+	go srv.reap(context.Background(), cfg.Token.ReapInterval.Duration)
+
+	s := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(logging.UnaryServerInterceptor, metrics.UnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(logging.StreamServerInterceptor, metrics.StreamServerInterceptor),
+	)
+	pb.RegisterTokenServiceServer(s, srv)
+
+	slog.Info("token service listening", "addr", cfg.Token.Addr, "quotas", quotaNames(quotas), "redis", cfg.Redis.Addr)
 	if err := s.Serve(lis); err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestServer(t *testing.T, limit int64) (*server, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	quotas, err := parseQuotas("", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &server{rdb: rdb, quotas: quotas, holdTTL: time.Minute}, mr
+}
+
+// 并发扣减同一用户：计数永远不超过上限，且等于放行次数 × 每次的 token 数
+func TestCheckAndIncConcurrent(t *testing.T) {
+	const (
+		limit   = 1000
+		tokens  = 7
+		workers = 50
+		calls   = 10 // 共 3500 个 token 的请求，只有一部分能放行
+	)
+	s, mr := newTestServer(t, limit)
+	ctx := context.Background()
+	key := s.quotas[0].key("u1", time.Now())
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < calls; i++ {
+				r, err := s.CheckAndInc(ctx, &pb.TokenRequest{UserId: "u1", Tokens: tokens})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if r.Allowed {
+					allowed.Add(1)
+				} else if r.Window != "tokens/day" || r.ResetAt <= time.Now().Unix() {
+					t.Errorf("denied reply = %v, want window tokens/day with a future reset_at", r)
+				}
+				if r.Remaining < 0 || r.Remaining > limit {
+					t.Errorf("remaining = %d, out of [0, %d]", r.Remaining, limit)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := s.rdb.Get(ctx, key).Int64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got > limit {
+		t.Fatalf("counter = %d, exceeds limit %d", got, limit)
+	}
+	if want := allowed.Load() * tokens; got != want {
+		t.Fatalf("counter = %d, want allowed(%d) × %d = %d", got, allowed.Load(), tokens, want)
+	}
+	if want := int64(limit / tokens * tokens); got != want {
+		t.Fatalf("counter = %d, want %d (quota filled up to the last whole request)", got, want)
+	}
+	if ttl := mr.TTL(key); ttl <= 0 {
+		t.Fatalf("counter has no TTL")
+	}
+}
+
+// 负数回冲在 0 处截断，不会把计数扣成负数
+func TestCheckAndIncNegativeClampsAtZero(t *testing.T) {
+	s, _ := newTestServer(t, 1000)
+	ctx := context.Background()
+	key := s.quotas[0].key("u1", time.Now())
+
+	if _, err := s.CheckAndInc(ctx, &pb.TokenRequest{UserId: "u1", Tokens: 300}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.CheckAndInc(ctx, &pb.TokenRequest{UserId: "u1", Tokens: -500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Allowed || r.Remaining != 1000 {
+		t.Fatalf("refund reply = %v, want allowed with remaining 1000", r)
+	}
+	if got, _ := s.rdb.Get(ctx, key).Int64(); got != 0 {
+		t.Fatalf("counter = %d after over-refund, want 0", got)
+	}
+
+	// 计数为 0 时再回冲也保持 0
+	if _, err := s.CheckAndInc(ctx, &pb.TokenRequest{UserId: "u1", Tokens: -1}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.rdb.Get(ctx, key).Int64(); got != 0 {
+		t.Fatalf("counter = %d, want 0", got)
+	}
+}
+
+// newQuotaServer 按 TOKEN_QUOTAS 的写法配置多个窗口
+func newQuotaServer(t *testing.T, spec string) (*server, *miniredis.Miniredis) {
+	t.Helper()
+	s, mr := newTestServer(t, 0)
+	quotas, err := parseQuotas(spec, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.quotas = quotas
+	return s, mr
+}
+
+// awayFromMinuteEdge 避免用例跨过分钟边界（计数 key 按自然分钟切换）
+func awayFromMinuteEdge() {
+	if now := time.Now(); now.Second() >= 58 {
+		time.Sleep(time.Until(now.Truncate(time.Minute).Add(time.Minute)))
+	}
+}
+
+// 多个窗口一次原子校验：任一窗口超限就整体拒绝、不动任何计数，并报告拒绝的窗口与它的重置时间
+func TestCheckAndIncWindows(t *testing.T) {
+	const spec = "tokens:100/minute,tokens:1000/day,tokens:5000/month,requests:3/day"
+	for _, tc := range []struct {
+		name    string
+		pre     []int64 // 各窗口（按 spec 顺序）已有的计数
+		tokens  int32
+		window  string // 拒绝的窗口，放行时为空
+		want    []int64
+		remains int64
+	}{
+		{"all fit", []int64{0, 0, 0, 0}, 50, "", []int64{50, 50, 50, 1}, 50},
+		{"minute over", []int64{80, 80, 80, 1}, 30, "tokens/minute", []int64{80, 80, 80, 1}, 20},
+		{"day over", []int64{0, 990, 990, 1}, 20, "tokens/day", []int64{0, 990, 990, 1}, 10},
+		{"month over", []int64{0, 0, 4990, 1}, 20, "tokens/month", []int64{0, 0, 4990, 1}, 10},
+		{"requests over", []int64{0, 0, 0, 3}, 1, "requests/day", []int64{0, 0, 0, 3}, 100},
+		{"first failing window reported", []int64{100, 1000, 0, 0}, 1, "tokens/minute", []int64{100, 1000, 0, 0}, 0},
+		{"refund allowed at the limit", []int64{100, 1000, 5000, 3}, -10, "", []int64{90, 990, 4990, 3}, 10},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			awayFromMinuteEdge()
+			s, mr := newQuotaServer(t, spec)
+			ctx := context.Background()
+			now := time.Now()
+			keys := make([]string, len(s.quotas))
+			for i, q := range s.quotas {
+				keys[i] = q.key("u1", now)
+				if tc.pre[i] > 0 {
+					mr.Set(keys[i], strconv.FormatInt(tc.pre[i], 10))
+				}
+			}
+
+			r, err := s.CheckAndInc(ctx, &pb.TokenRequest{UserId: "u1", Tokens: tc.tokens})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Allowed != (tc.window == "") || r.Window != tc.window {
+				t.Fatalf("reply = %v, want allowed=%v window=%q", r, tc.window == "", tc.window)
+			}
+			if r.Remaining != tc.remains {
+				t.Fatalf("remaining = %d, want %d", r.Remaining, tc.remains)
+			}
+			if tc.window == "" {
+				if r.ResetAt != 0 {
+					t.Fatalf("reset_at = %d on an allowed reply, want 0", r.ResetAt)
+				}
+			} else {
+				i := slices.IndexFunc(s.quotas, func(q quota) bool { return q.name() == tc.window })
+				if want := s.quotas[i].reset(now).Unix(); r.ResetAt != want {
+					t.Fatalf("reset_at = %d, want %d (reset of %s)", r.ResetAt, want, tc.window)
+				}
+			}
+			for i, key := range keys {
+				got, _ := s.rdb.Get(ctx, key).Int64()
+				if got != tc.want[i] {
+					t.Fatalf("%s counter = %d, want %d", s.quotas[i].name(), got, tc.want[i])
+				}
+				if tc.window != "" && tc.pre[i] == 0 && mr.Exists(key) {
+					t.Fatalf("%s counter created by a denied request", s.quotas[i].name())
+				}
+			}
+		})
+	}
+}
+
+// 预占与 CheckAndInc 共用同一段校验：被某个窗口拒绝时不扣计数，也不留下预占记录
+func TestReserveDeniedByWindow(t *testing.T) {
+	awayFromMinuteEdge()
+	s, mr := newQuotaServer(t, "tokens:100/minute,tokens:1000/day,requests:10/day")
+	now := time.Now()
+	mr.Set(s.quotas[0].key("u1", now), "90")
+
+	r, err := s.Reserve(context.Background(), &pb.ReserveRequest{UserId: "u1", Tokens: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Allowed || r.Window != "tokens/minute" || r.ReservationId != "" {
+		t.Fatalf("reply = %v, want denied by tokens/minute without a reservation", r)
+	}
+	if want := s.quotas[0].reset(now).Unix(); r.ResetAt != want {
+		t.Fatalf("reset_at = %d, want %d", r.ResetAt, want)
+	}
+	if mr.Exists(s.quotas[1].key("u1", now)) || mr.Exists(s.quotas[2].key("u1", now)) {
+		t.Fatal("denied reserve touched the other windows")
+	}
+	if n, _ := s.rdb.ZCard(context.Background(), pendingKey).Result(); n != 0 {
+		t.Fatalf("pending reservations = %d, want 0", n)
+	}
+}
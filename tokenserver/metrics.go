@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// 配额不足被拒绝的次数（按 RPC 与拒绝的窗口）
+var quotaDenied = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "quota_denied_total",
+	Help: "Requests denied because a quota window is exhausted, by RPC and window (e.g. tokens/minute).",
+}, []string{"rpc", "window"})
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+)
+
+// 窗口结束后计数 key 再保留一段时间，让跨窗口的预占还能在原窗口里结算
+const settleGrace = time.Hour
+
+// 预占记录的有效期：远长于预占有效期，由 commit/release/reaper 提前删除
+const recordTTL = 48 * time.Hour
+
+// quota 是一个配额窗口：每个自然周期内最多 limit 个 token（或 limit 次请求）
+type quota struct {
+	unit   string // tokens | requests
+	limit  int64
+	period string // minute | hour | day | month
+}
+
+func (q quota) name() string { return q.unit + "/" + q.period }
+
+// quotaNames 按配置写法列出各窗口（启动日志用），如 "tokens:2000/minute,requests:200/day"
+func quotaNames(quotas []quota) string {
+	names := make([]string, len(quotas))
+	for i, q := range quotas {
+		names[i] = fmt.Sprintf("%s:%d/%s", q.unit, q.limit, q.period)
+	}
+	return strings.Join(names, ",")
+}
+
+// parseQuotas 解析 token.quotas（TOKEN_QUOTAS），如 "tokens:2000/minute,tokens:5000/day,requests:200/day"。
+// 为空时只有 tokens:<daily_limit>/day，与原来的每日上限一致。错误里不带配置项名，由调用方加上来源
+func parseQuotas(spec string, dailyLimit int64) ([]quota, error) {
+	if strings.TrimSpace(spec) == "" {
+		return []quota{{unit: "tokens", limit: dailyLimit, period: "day"}}, nil
+	}
+	var quotas []quota
+	seen := map[string]bool{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		unit, rest, _ := strings.Cut(part, ":")
+		n, per, ok := strings.Cut(rest, "/")
+		if !ok {
+			return nil, fmt.Errorf("%q: want unit:N/period", part)
+		}
+		if unit != "tokens" && unit != "requests" {
+			return nil, fmt.Errorf("unknown unit %q (want tokens|requests)", unit)
+		}
+		limit, err := strconv.ParseInt(n, 10, 64)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("%q: bad limit", part)
+		}
+		if per != "minute" && per != "hour" && per != "day" && per != "month" {
+			return nil, fmt.Errorf("%q: bad period (want minute|hour|day|month)", part)
+		}
+		q := quota{unit: unit, limit: limit, period: per}
+		if seen[q.name()] {
+			return nil, fmt.Errorf("duplicate window %s", q.name())
+		}
+		seen[q.name()] = true
+		quotas = append(quotas, q)
+	}
+	if len(quotas) == 0 {
+		return nil, fmt.Errorf("no windows in %q", spec)
+	}
+	return quotas, nil
+}
+
+// reset 返回 now 所在周期的结束（重置）时间，按本地时区的自然分钟/小时/日/月划分
+func (q quota) reset(now time.Time) time.Time {
+	y, m, d := now.Date()
+	switch q.period {
+	case "minute":
+		return time.Date(y, m, d, now.Hour(), now.Minute()+1, 0, 0, now.Location())
+	case "hour":
+		return time.Date(y, m, d, now.Hour()+1, 0, 0, 0, now.Location())
+	case "day":
+		return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+	default: // month
+		return time.Date(y, m+1, 1, 0, 0, 0, 0, now.Location())
+	}
+}
+
+// key 返回 now 所在周期的计数 key。tokens/day 沿用原来的 token:{user}:{yyyy-mm-dd}，升级后当日已用量不清零
+func (q quota) key(user string, now time.Time) string {
+	layout := map[string]string{
+		"minute": "2006-01-02T15:04", "hour": "2006-01-02T15", "day": "2006-01-02", "month": "2006-01",
+	}[q.period]
+	prefix := "token"
+	if q.unit == "requests" {
+		prefix = "req"
+	}
+	return fmt.Sprintf("%s:%s:%s", prefix, user, now.Format(layout))
+}
+
+// windows 给出各配额窗口当前的计数 key，以及 Lua 脚本用的 (增量, 上限, TTL 秒) 参数。
+// token 窗口增量为 tokens；请求窗口每次扣 1，tokens <= 0（回冲）时不计请求
+func (s *server) windows(user string, tokens int32, now time.Time) ([]string, []any) {
+	keys := make([]string, len(s.quotas))
+	args := make([]any, 0, 3*len(s.quotas))
+	for i, q := range s.quotas {
+		delta := int64(tokens)
+		if q.unit == "requests" {
+			delta = 0
+			if tokens > 0 {
+				delta = 1
+			}
+		}
+		keys[i] = q.key(user, now)
+		args = append(args, delta, q.limit, int64((q.reset(now).Sub(now)+settleGrace)/time.Second))
+	}
+	return keys, args
+}
+
+// splitKeys 把计数 key 分成 token 窗口（结算时按真实用量调整）与请求窗口两组
+func (s *server) splitKeys(keys []string) (tokens, requests []string) {
+	for i, q := range s.quotas {
+		if q.unit == "tokens" {
+			tokens = append(tokens, keys[i])
+		} else {
+			requests = append(requests, keys[i])
+		}
+	}
+	return tokens, requests
+}
+
+// remaining 是各 token 窗口里最少的剩余量；只配置了请求窗口时取请求窗口
+func (s *server) remaining(counts []int64) int64 {
+	unit := "requests"
+	for _, q := range s.quotas {
+		if q.unit == "tokens" {
+			unit = "tokens"
+			break
+		}
+	}
+	rem := int64(math.MaxInt64)
+	for i, q := range s.quotas {
+		if q.unit == unit {
+			rem = min(rem, q.limit-counts[i])
+		}
+	}
+	return max(0, rem)
+}
+
+// verdict 把脚本返回的 {allowed, 拒绝的窗口序号（从 1 起，放行时为 0）, 各窗口计数...} 转成回复
+func (s *server) verdict(res []int64, now time.Time) *pb.TokenReply {
+	reply := &pb.TokenReply{Allowed: res[0] == 1, Remaining: s.remaining(res[2:])}
+	if i := res[1]; i > 0 {
+		q := s.quotas[i-1]
+		reply.Window, reply.ResetAt = q.name(), q.reset(now).Unix()
+	}
+	return reply
+}
+
+// current 读取各窗口当前计数，算出剩余量（结算、释放之后回给网关，仅供展示）
+func (s *server) current(ctx context.Context, user string) int64 {
+	keys, _ := s.windows(user, 0, time.Now())
+	vals, err := s.rdb.MGet(ctx, keys...).Result()
+	counts := make([]int64, len(keys))
+	if err == nil {
+		for i, v := range vals {
+			if str, ok := v.(string); ok {
+				counts[i], _ = strconv.ParseInt(str, 10, 64)
+			}
+		}
+	}
+	return s.remaining(counts)
+}
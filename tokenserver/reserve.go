@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 所有未结算预占：zset，score = 过期时间戳（秒），member = reservation_id
+const pendingKey = "tokenres:pending"
+
+// 单笔预占记录：hash{user: 所属用户, tkeys: token 窗口计数 key（空格分隔）, rkeys: 请求窗口计数 key, tokens: 预占数}。
+// 升级前的记录只有 key 字段（当日计数 key）、没有 user，按只有一个 token 窗口处理、不校验所属用户
+func resKey(id string) string { return "tokenres:" + id }
+
+// 已结算标记：值为所属用户。重试或重复的 Commit 看到它直接返回，不会再按全额扣一次
+func doneKey(id string) string { return "tokenres:done:" + id }
+
+// 已结算标记的有效期，覆盖网关的重试即可
+const doneTTL = 10 * time.Minute
+
+// 脚本返回值：预占属于别的用户
+const notOwner = -2
+
+func newReservationID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// 预占：各窗口计数 + 超限判断 + 记录预占，一次完成
+// KEYS[1..n]=各窗口计数 key  KEYS[n+1]=预占记录  KEYS[n+2]=pending zset
+// ARGV[1..3n]=各窗口 (增量, 上限, TTL 秒)，之后依次为 tkeys、rkeys、tokens、记录 TTL 秒、过期时间戳、reservation_id、user
+var reserveScript = redis.NewScript(`local n = #KEYS - 2` + applyQuotasLua + `
+if res[1] == 0 then return res end
+redis.call('HSET', KEYS[n+1], 'user', ARGV[3*n+7], 'tkeys', ARGV[3*n+1], 'rkeys', ARGV[3*n+2], 'tokens', ARGV[3*n+3])
+redis.call('EXPIRE', KEYS[n+1], ARGV[3*n+4])
+redis.call('ZADD', KEYS[n+2], ARGV[3*n+5], ARGV[3*n+6])
+return res
+`)
+
+// 结算：按真实用量多退少补，只调整 token 窗口（请求数在预占时已计）；
+// 预占时所在的窗口已过期的不再补记。预占记录已不存在（过期被回收）时按全额计入当前各 token 窗口。
+// 结算后留下已结算标记，同一 reservation_id 再次结算直接返回 2；预占或标记属于别的用户时返回 -2
+// KEYS[1]=预占记录  KEYS[2]=pending zset  KEYS[3]=已结算标记  KEYS[4..]=当前各 token 窗口计数 key
+// ARGV[1]=reservation_id  ARGV[2]=真实用量  ARGV[3]=user  ARGV[4]=标记 TTL 秒  ARGV[5..]=对应计数 key 的 TTL 秒
+var commitScript = redis.NewScript(`
+local done = redis.call('GET', KEYS[3])
+if done then
+  if done ~= ARGV[3] then return -2 end
+  return 2
+end
+local owner = redis.call('HGET', KEYS[1], 'user')
+if owner and owner ~= ARGV[3] then return -2 end
+redis.call('SET', KEYS[3], ARGV[3], 'EX', ARGV[4])
+local tkeys = redis.call('HGET', KEYS[1], 'tkeys') or redis.call('HGET', KEYS[1], 'key')
+local delta = tonumber(ARGV[2])
+if tkeys then
+  delta = delta - tonumber(redis.call('HGET', KEYS[1], 'tokens'))
+  redis.call('DEL', KEYS[1])
+  redis.call('ZREM', KEYS[2], ARGV[1])
+  for key in string.gmatch(tkeys, '%S+') do
+    if redis.call('EXISTS', key) == 1 then
+      local val = redis.call('INCRBY', key, delta)
+      if val < 0 then redis.call('INCRBY', key, -val) end
+    end
+  end
+  return 1
+end
+for i = 4, #KEYS do
+  local val = redis.call('INCRBY', KEYS[i], delta)
+  if redis.call('TTL', KEYS[i]) < 0 then redis.call('EXPIRE', KEYS[i], ARGV[i+1]) end
+  if val < 0 then redis.call('INCRBY', KEYS[i], -val) end
+end
+return 0
+`)
+
+// 释放：退回整笔预占（token 窗口退 tokens，请求窗口退 1）；记录不存在时返回 -1（幂等），属于别的用户时返回 -2
+// KEYS[1]=预占记录  KEYS[2]=pending zset  ARGV[1]=reservation_id  ARGV[2]=user（为空时不校验，供回收使用）
+var releaseScript = redis.NewScript(`
+local owner = redis.call('HGET', KEYS[1], 'user')
+if owner and ARGV[2] ~= '' and owner ~= ARGV[2] then return -2 end
+local tkeys = redis.call('HGET', KEYS[1], 'tkeys') or redis.call('HGET', KEYS[1], 'key')
+redis.call('ZREM', KEYS[2], ARGV[1])
+if not tkeys then return -1 end
+local refund = function(keys, n)
+  for key in string.gmatch(keys, '%S+') do
+    if redis.call('EXISTS', key) == 1 then
+      local val = redis.call('DECRBY', key, n)
+      if val < 0 then redis.call('INCRBY', key, -val) end
+    end
+  end
+end
+refund(tkeys, redis.call('HGET', KEYS[1], 'tokens'))
+refund(redis.call('HGET', KEYS[1], 'rkeys') or '', 1)
+redis.call('DEL', KEYS[1])
+return 1
+`)
+
+func (s *server) Reserve(ctx context.Context, in *pb.ReserveRequest) (*pb.ReserveReply, error) {
+	hold := s.holdTTL
+	if in.TtlSeconds > 0 {
+		hold = time.Duration(in.TtlSeconds) * time.Second
+	}
+	id := newReservationID()
+	now := time.Now()
+
+	keys, args := s.windows(in.UserId, in.Tokens, now)
+	tkeys, rkeys := s.splitKeys(keys)
+	args = append(args, strings.Join(tkeys, " "), strings.Join(rkeys, " "), in.Tokens,
+		int64(recordTTL/time.Second), now.Add(hold).Unix(), id, in.UserId)
+	res, err := reserveScript.Run(ctx, s.rdb, append(keys, resKey(id), pendingKey), args...).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	v := s.verdict(res, now)
+	reply := &pb.ReserveReply{Allowed: v.Allowed, Remaining: v.Remaining, Window: v.Window, ResetAt: v.ResetAt}
+	if reply.Allowed {
+		reply.ReservationId = id
+	} else {
+		quotaDenied.WithLabelValues("Reserve", reply.Window).Inc()
+	}
+	return reply, nil
+}
+
+func (s *server) Commit(ctx context.Context, in *pb.CommitRequest) (*pb.TokenReply, error) {
+	keys, args := s.windows(in.UserId, 0, time.Now())
+	argv := []any{in.ReservationId, in.Tokens, in.UserId, int64(doneTTL / time.Second)}
+	var tkeys []string
+	for i, q := range s.quotas {
+		if q.unit == "tokens" {
+			tkeys = append(tkeys, keys[i])
+			argv = append(argv, args[3*i+2])
+		}
+	}
+	val, err := commitScript.Run(ctx, s.rdb,
+		append([]string{resKey(in.ReservationId), pendingKey, doneKey(in.ReservationId)}, tkeys...), argv...,
+	).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if val == notOwner {
+		return nil, status.Error(codes.PermissionDenied, "reservation belongs to another user")
+	}
+	return &pb.TokenReply{Allowed: true, Remaining: s.current(ctx, in.UserId)}, nil
+}
+
+func (s *server) Release(ctx context.Context, in *pb.ReleaseRequest) (*pb.TokenReply, error) {
+	// 已释放/已回收时同样返回当前剩余量
+	val, err := s.release(ctx, in.ReservationId, in.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if val == notOwner {
+		return nil, status.Error(codes.PermissionDenied, "reservation belongs to another user")
+	}
+	return &pb.TokenReply{Allowed: true, Remaining: s.current(ctx, in.UserId)}, nil
+}
+
+// release 执行释放脚本；user 为空时不校验所属用户（回收）
+func (s *server) release(ctx context.Context, id, user string) (int64, error) {
+	return releaseScript.Run(ctx, s.rdb, []string{resKey(id), pendingKey}, id, user).Int64()
+}
+
+// reap 定期回收过期未结算的预占（网关崩溃、连接中断等留下的孤儿预占）。
+// 释放脚本是幂等的，多个 tokenserver 实例同时回收也不会重复退回。
+func (s *server) reap(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		ids, err := s.rdb.ZRangeByScore(ctx, pendingKey, &redis.ZRangeBy{
+			Min: "-inf", Max: strconv.FormatInt(time.Now().Unix(), 10), Count: 100,
+		}).Result()
+		if err != nil {
+			continue
+		}
+		for _, id := range ids {
+			if _, err := s.release(ctx, id, ""); err == nil {
+				slog.Info("reaped expired reservation", "reservation_id", id)
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "chatgpt-demo/chatpb"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func count(t *testing.T, s *server, key string) int64 {
+	t.Helper()
+	n, err := s.rdb.Get(context.Background(), key).Int64()
+	if err != nil && err != redis.Nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func reserve(t *testing.T, s *server, user string, tokens int32) string {
+	t.Helper()
+	r, err := s.Reserve(context.Background(), &pb.ReserveRequest{UserId: user, Tokens: tokens})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Allowed || r.ReservationId == "" {
+		t.Fatalf("reserve reply = %v, want allowed with a reservation id", r)
+	}
+	return r.ReservationId
+}
+
+func commit(s *server, user, id string, tokens int32) error {
+	_, err := s.Commit(context.Background(), &pb.CommitRequest{UserId: user, ReservationId: id, Tokens: tokens})
+	return err
+}
+
+func release(s *server, user, id string) error {
+	_, err := s.Release(context.Background(), &pb.ReleaseRequest{UserId: user, ReservationId: id})
+	return err
+}
+
+// 结算按真实用量多退少补；重复结算（网关重试）不会再扣一次
+func TestCommitIdempotent(t *testing.T) {
+	s, mr := newTestServer(t, 1000)
+	key := s.quotas[0].key("u1", time.Now())
+
+	id := reserve(t, s, "u1", 100)
+	if got := count(t, s, key); got != 100 {
+		t.Fatalf("counter after reserve = %d, want 100", got)
+	}
+	if err := commit(s, "u1", id, 30); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(t, s, key); got != 30 {
+		t.Fatalf("counter after commit = %d, want 30", got)
+	}
+	if mr.Exists(resKey(id)) {
+		t.Fatal("reservation record left after commit")
+	}
+	if ttl := mr.TTL(doneKey(id)); ttl <= 0 || ttl > doneTTL {
+		t.Fatalf("done marker ttl = %v, want (0, %v]", ttl, doneTTL)
+	}
+
+	for _, tokens := range []int32{30, 500} {
+		if err := commit(s, "u1", id, tokens); err != nil {
+			t.Fatal(err)
+		}
+		if got := count(t, s, key); got != 30 {
+			t.Fatalf("counter after repeated commit(%d) = %d, want 30", tokens, got)
+		}
+	}
+
+	// 已结算的预占再释放是空操作
+	if err := release(s, "u1", id); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(t, s, key); got != 30 {
+		t.Fatalf("counter after release of a committed reservation = %d, want 30", got)
+	}
+}
+
+// 别的用户不能结算或释放这笔预占，计数与记录都不变
+func TestReservationOwner(t *testing.T) {
+	s, mr := newTestServer(t, 1000)
+	key := s.quotas[0].key("u1", time.Now())
+	id := reserve(t, s, "u1", 100)
+
+	for name, err := range map[string]error{
+		"commit":  commit(s, "u2", id, 10),
+		"release": release(s, "u2", id),
+	} {
+		if status.Code(err) != codes.PermissionDenied {
+			t.Fatalf("%s by another user: err = %v, want PermissionDenied", name, err)
+		}
+	}
+	if got := count(t, s, key); got != 100 {
+		t.Fatalf("counter = %d, want 100 (untouched)", got)
+	}
+	if !mr.Exists(resKey(id)) || mr.Exists(doneKey(id)) {
+		t.Fatal("record consumed or done marker set by a rejected commit")
+	}
+
+	if err := commit(s, "u1", id, 40); err != nil {
+		t.Fatal(err)
+	}
+	// 结算之后已结算标记同样校验所属用户
+	if err := commit(s, "u2", id, 40); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("repeat commit by another user: err = %v, want PermissionDenied", err)
+	}
+	if got := count(t, s, key); got != 40 {
+		t.Fatalf("counter = %d, want 40", got)
+	}
+}
+
+// 释放退回整笔预占（token 窗口与请求窗口），再次释放是空操作
+func TestRelease(t *testing.T) {
+	s, _ := newTestServer(t, 1000)
+	quotas, err := parseQuotas("tokens:1000/day,requests:10/day", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.quotas = quotas
+	now := time.Now()
+	tkey, rkey := s.quotas[0].key("u1", now), s.quotas[1].key("u1", now)
+
+	id := reserve(t, s, "u1", 100)
+	if count(t, s, tkey) != 100 || count(t, s, rkey) != 1 {
+		t.Fatalf("counters after reserve = %d/%d, want 100/1", count(t, s, tkey), count(t, s, rkey))
+	}
+	for i := 0; i < 2; i++ {
+		if err := release(s, "u1", id); err != nil {
+			t.Fatal(err)
+		}
+		if count(t, s, tkey) != 0 || count(t, s, rkey) != 0 {
+			t.Fatalf("counters after release #%d = %d/%d, want 0/0", i+1, count(t, s, tkey), count(t, s, rkey))
+		}
+	}
+}
+
+// 预占已被回收（记录不存在）后才结算：按真实用量全额计入当前窗口，并补上 TTL
+func TestCommitAfterReaped(t *testing.T) {
+	s, mr := newTestServer(t, 1000)
+	key := s.quotas[0].key("u1", time.Now())
+	id := reserve(t, s, "u1", 100)
+
+	if _, err := s.release(context.Background(), id, ""); err != nil {
+		t.Fatal(err)
+	}
+	mr.Del(key) // 计数 key 也已过期
+	if err := commit(s, "u1", id, 40); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(t, s, key); got != 40 {
+		t.Fatalf("counter = %d, want 40", got)
+	}
+	if ttl := mr.TTL(key); ttl <= 0 {
+		t.Fatal("counter recreated by commit has no TTL")
+	}
+	if err := commit(s, "u1", id, 40); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(t, s, key); got != 40 {
+		t.Fatalf("counter after repeated commit = %d, want 40", got)
+	}
+}
+
+// 升级前的预占记录只有 key 字段、没有 user：照常结算，不校验所属用户
+func TestCommitLegacyRecord(t *testing.T) {
+	s, mr := newTestServer(t, 1000)
+	key := s.quotas[0].key("u1", time.Now())
+	mr.Set(key, "100")
+	mr.HSet(resKey("old"), "key", key, "tokens", "100")
+	mr.ZAdd(pendingKey, float64(time.Now().Add(time.Minute).Unix()), "old")
+
+	if err := commit(s, "u1", "old", 30); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(t, s, key); got != 30 {
+		t.Fatalf("counter = %d, want 30", got)
+	}
+	if mr.Exists(resKey("old")) {
+		t.Fatal("legacy record left after commit")
+	}
+}
+
+// reaper 只退回过期的预占
+func TestReapExpired(t *testing.T) {
+	s, mr := newTestServer(t, 1000)
+	key := s.quotas[0].key("u1", time.Now())
+	expired := reserve(t, s, "u1", 100)
+	live := reserve(t, s, "u1", 50)
+	mr.ZAdd(pendingKey, float64(time.Now().Add(-time.Second).Unix()), expired)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.reap(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for count(t, s, key) != 50 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if got := count(t, s, key); got != 50 {
+		t.Fatalf("counter = %d, want 50 (expired reservation returned)", got)
+	}
+	if mr.Exists(resKey(expired)) || !mr.Exists(resKey(live)) {
+		t.Fatal("reaper removed the wrong reservation")
+	}
+	if err := commit(s, "u1", live, 20); err != nil {
+		t.Fatal(err)
+	}
+	if got := count(t, s, key); got != 20 {
+		t.Fatalf("counter after committing the live reservation = %d, want 20", got)
+	}
+}
@@ -0,0 +1,28 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/XSAM/otelsql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OpenMySQL 等价于 sql.Open("mysql", dsn)，但每次查询/执行都带一个 client span（含 SQL 语句）。
+// 与 Redis 一样只在已有父 span 时创建；逐行读取、会话重置、建连不单独出 span。
+func OpenMySQL(dsn string) (*sql.DB, error) {
+	return otelsql.Open("mysql", dsn,
+		otelsql.WithAttributes(semconv.DBSystemNameMySQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableErrSkip:       true,
+			OmitRows:             true,
+			OmitConnResetSession: true,
+			OmitConnectorConnect: true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
+}
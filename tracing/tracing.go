@@ -0,0 +1,95 @@
+// Package tracing 汇总各服务共用的 OpenTelemetry 初始化：TracerProvider、W3C 传播器与导出器选择，
+// 以及 Redis 的 span hook。gRPC 的跨进程传播由各服务挂上 otelgrpc 的 stats handler 完成。
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Init 安装全局 TracerProvider 与 W3C tracecontext/baggage 传播器，按 OTEL_TRACES_EXPORTER 选择导出器：
+//
+//	none（默认）  只生成与透传 trace_id，不导出
+//	otlp          OTLP/gRPC，地址等用标准环境变量（OTEL_EXPORTER_OTLP_ENDPOINT，默认 localhost:4317）
+//	stdout        逐个 span 打印到标准输出（本地调试）
+//	file          逐个 span 追加写入 OTEL_TRACES_FILE（默认 traces-<service>.jsonl）
+//
+// 服务名可用 OTEL_SERVICE_NAME 覆盖，采样可用 OTEL_TRACES_SAMPLER 配置（默认全采样、跟随上游）。
+// 返回的 shutdown 在退出前调用，刷出尚未导出的 span。
+func Init(ctx context.Context, service string) (shutdown func(context.Context) error, err error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(service)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	var closer io.Closer
+	switch exp := os.Getenv("OTEL_TRACES_EXPORTER"); exp {
+	case "", "none":
+	case "otlp":
+		e, err := otlptracegrpc.New(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(e))
+	case "stdout", "console":
+		e, err := stdouttrace.New()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithSyncer(e))
+	case "file":
+		path := os.Getenv("OTEL_TRACES_FILE")
+		if path == "" {
+			path = "traces-" + service + ".jsonl"
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		e, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithSyncer(e))
+		closer = f
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q (want none|otlp|stdout|file)", exp)
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			closer.Close()
+		}
+		return err
+	}, nil
+}
+
+// TraceID 返回 ctx 里当前 span 的 trace_id（没有时为空）
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}